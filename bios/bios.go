@@ -26,10 +26,29 @@ type BIOS struct {
 	HackVotingAccounts bool
 	ReuseGenesis       bool
 
+	// BootSequenceVars override the `vars:` of the boot sequence, and
+	// BootSequenceProfiles are the active `profiles:`.
+	BootSequenceVars     map[string]interface{}
+	BootSequenceProfiles []string
+
 	Genesis *GenesisJSON
 
+	// PublishGenesis is called by `Boot` once the boot node is up, so
+	// other participants can join while the boot sequence is injected.
+	PublishGenesis func(genesisJSON string) error
+
 	EphemeralPrivateKey *ecc.PrivateKey
 	EphemeralPublicKey  ecc.PublicKey
+
+	// permissionKeys sign for the permissions of accounts the
+	// ephemeral key doesn't control, like the approvers of an
+	// `msig.approve`.
+	permissionKeys map[eos.PermissionLevel][]ecc.PublicKey
+
+	// namedKeys are the public keys of the `keys:` section read so
+	// far, and privateKeyNames those whose private key is loaded.
+	namedKeys       map[string]ecc.PublicKey
+	privateKeyNames map[string]bool
 }
 
 func NewBIOS(logger *Logger, cachePath string, targetAPI *eos.API) *BIOS {
@@ -42,7 +61,7 @@ func NewBIOS(logger *Logger, cachePath string, targetAPI *eos.API) *BIOS {
 }
 
 func (b *BIOS) Boot() error {
-	bootSeq, err := ReadBootSeq(b.BootSequenceFile)
+	bootSeq, err := ReadBootSeq(b.BootSequenceFile, b.BootSequenceVars, b.BootSequenceProfiles)
 	if err != nil {
 		return err
 	}
@@ -82,7 +101,7 @@ func (b *BIOS) Boot() error {
 	// latency.. and we KNOW the key you're going to ask :) It's the
 	// only key we're going to sign with anyway..
 	b.TargetNetAPI.SetCustomGetRequiredKeys(func(tx *eos.Transaction) (out []ecc.PublicKey, err error) {
-		return append(out, pubKey), nil
+		return b.requiredKeys(tx, pubKey), nil
 	})
 
 	// Store keys in wallet, to sign `SetCode` and friends..
@@ -90,6 +109,10 @@ func (b *BIOS) Boot() error {
 		return fmt.Errorf("ImportWIF: %s", err)
 	}
 
+	if err := b.loadKeys(); err != nil {
+		return err
+	}
+
 	if err := b.writeAllActionsToDisk(); err != nil {
 		return fmt.Errorf("writing actions to disk: %s", err)
 	}
@@ -100,6 +123,12 @@ func (b *BIOS) Boot() error {
 
 	b.pingTargetNetwork()
 
+	if b.PublishGenesis != nil {
+		if err := b.PublishGenesis(genesisData); err != nil {
+			return fmt.Errorf("publishing genesis: %s", err)
+		}
+	}
+
 	b.Log.Println("In-memory keys:")
 	memkeys, _ := b.TargetNetAPI.Signer.AvailableKeys()
 	for _, key := range memkeys {
@@ -112,30 +141,53 @@ func (b *BIOS) Boot() error {
 	for _, step := range b.BootSequence.BootSequence {
 		b.Log.Printf("%s  [%s] ", step.Label, step.Op)
 
-		acts, err := step.Data.Actions(b)
-		if err != nil {
-			return fmt.Errorf("getting actions for step %q: %s", step.Op, err)
-		}
-
-		if len(acts) != 0 {
-			for idx, chunk := range ChunkifyActions(acts) {
-				err := Retry(25, time.Second, func() error {
-					_, err := b.TargetNetAPI.SignPushActions(chunk...)
-					if err != nil {
-						b.Log.Printf("r")
-						b.Log.Debugf("error pushing transaction for step %q, chunk %d: %s\n", step.Op, idx, err)
-						return fmt.Errorf("push actions for step %q, chunk %d: %s", step.Op, idx, err)
-					}
-					return nil
-				})
+		if apiOp, ok := step.Data.(APIOperation); ok {
+			if err := apiOp.Run(b); err != nil {
+				b.Log.Printf(" failed\n")
+				return fmt.Errorf("step %q: %s", step.Op, err)
+			}
+			b.Log.Printf(" done\n")
+			continue
+		}
+
+		if importer, ok := step.Data.(KeyImporter); ok {
+			if err := importer.ImportKeys(b); err != nil {
+				b.Log.Printf(" failed\n")
+				return fmt.Errorf("step %q: %s", step.Op, err)
+			}
+		}
+
+		idx := 0
+		err := b.streamChunks(step.Data, func(chunk []*eos.Action) error {
+			err := Retry(25, time.Second, func() error {
+				_, err := b.TargetNetAPI.SignPushActions(chunk...)
 				if err != nil {
-					b.Log.Printf(" failed\n")
-					return err
+					b.Log.Printf("r")
+					b.Log.Debugf("error pushing transaction for step %q, chunk %d: %s\n", step.Op, idx, err)
+					return fmt.Errorf("push actions for step %q, chunk %d: %s", step.Op, idx, err)
 				}
-				b.Log.Printf(".")
+				return nil
+			})
+			if err != nil {
+				b.Log.Printf(" failed\n")
+				return err
 			}
+			b.Log.Printf(".")
+			idx++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("step %q: %s", step.Op, err)
+		}
+		if idx != 0 {
 			b.Log.Printf(" done\n")
 		}
+
+		if voters, ok := step.Data.(*OpCreateVoters); ok {
+			if err := voters.Export(b); err != nil {
+				return fmt.Errorf("step %q: %s", step.Op, err)
+			}
+		}
 	}
 
 	b.Log.Println("Waiting 2 seconds for transactions to flush to blocks")
@@ -154,12 +206,65 @@ func (b *BIOS) Boot() error {
 	return nil
 }
 
+// Join starts a node on the network booted with `genesisJSON`
+// through the `join_network` hook, waits for it to receive blocks and,
+// with `validate`, checks the chain against the boot sequence.
+func (b *BIOS) Join(genesisJSON string, p2pAddresses []string, validate bool) error {
+	var genesis *GenesisJSON
+	if err := json.Unmarshal([]byte(genesisJSON), &genesis); err != nil {
+		return fmt.Errorf("invalid genesis data: %s", err)
+	}
+	b.Genesis = genesis
+
+	if validate {
+		bootSeq, err := ReadBootSeq(b.BootSequenceFile, b.BootSequenceVars, b.BootSequenceProfiles)
+		if err != nil {
+			return err
+		}
+		b.BootSequence = bootSeq
+
+		if err := b.DownloadReferences(); err != nil {
+			return err
+		}
+
+		// The boot node injected the boot sequence with the key of the
+		// genesis, actions referring to `ephemeral` carry it.
+		pubKey, err := ecc.NewPublicKey(genesis.InitialKey)
+		if err != nil {
+			return fmt.Errorf("genesis initial_key: %s", err)
+		}
+		b.EphemeralPublicKey = pubKey
+	}
+
+	if err := b.DispatchJoinNetwork(genesisJSON, p2pAddresses); err != nil {
+		return fmt.Errorf("dispatch join_network hook: %s", err)
+	}
+
+	b.pingTargetNetwork()
+
+	if !validate {
+		return nil
+	}
+
+	isValid, err := b.RunChainValidation()
+	if err != nil {
+		return fmt.Errorf("chain validation: %s", err)
+	}
+	if !isValid {
+		return fmt.Errorf("chain validation failed, the network wasn't booted with this boot sequence")
+	}
+
+	return nil
+}
+
 func (b *BIOS) setEphemeralKeypair() error {
-	if _, ok := b.BootSequence.Keys["ephemeral"]; ok {
-		cnt := b.BootSequence.Keys["ephemeral"]
-		privKey, err := ecc.NewPrivateKey(strings.TrimSpace(string(cnt)))
+	if def, ok := b.BootSequence.Keys["ephemeral"]; ok {
+		privKey, err := def.LoadPrivateKey()
 		if err != nil {
-			return fmt.Errorf("unable to correctly decode ephemeral private key %q: %s", cnt, err)
+			return fmt.Errorf("unable to correctly decode ephemeral private key: %s", err)
+		}
+		if privKey == nil {
+			return fmt.Errorf("the ephemeral key needs a private key")
 		}
 
 		b.EphemeralPrivateKey = privKey
@@ -191,6 +296,67 @@ func (b *BIOS) setEphemeralKeypair() error {
 	return nil
 }
 
+// ImportPermissionKeys loads the private keys of `keyFile`, one per
+// line, to sign for `level` instead of the ephemeral key.
+func (b *BIOS) ImportPermissionKeys(level eos.PermissionLevel, keyFile string) error {
+	keyBag := eos.NewKeyBag()
+	if err := keyBag.ImportFromFile(keyFile); err != nil {
+		return fmt.Errorf("reading keys of %s@%s: %s", level.Actor, level.Permission, err)
+	}
+	if len(keyBag.Keys) == 0 {
+		return fmt.Errorf("no keys for %s@%s in %q", level.Actor, level.Permission, keyFile)
+	}
+
+	if b.permissionKeys == nil {
+		b.permissionKeys = map[eos.PermissionLevel][]ecc.PublicKey{}
+	}
+
+	var pubKeys []ecc.PublicKey
+	for _, privKey := range keyBag.Keys {
+		if b.TargetNetAPI != nil && b.TargetNetAPI.Signer != nil {
+			if err := b.TargetNetAPI.Signer.ImportPrivateKey(privKey.String()); err != nil {
+				return fmt.Errorf("importing key of %s@%s: %s", level.Actor, level.Permission, err)
+			}
+		}
+		pubKeys = append(pubKeys, privKey.PublicKey())
+	}
+	b.permissionKeys[level] = pubKeys
+
+	return nil
+}
+
+// requiredKeys are the keys signing `tx`: those imported for the
+// permissions its actions are authorized by, and `defaultKey` for
+// all other permissions.
+func (b *BIOS) requiredKeys(tx *eos.Transaction, defaultKey ecc.PublicKey) (out []ecc.PublicKey) {
+	seen := map[string]bool{}
+	add := func(key ecc.PublicKey) {
+		if !seen[key.String()] {
+			seen[key.String()] = true
+			out = append(out, key)
+		}
+	}
+
+	for _, act := range tx.Actions {
+		for _, level := range act.Authorization {
+			keys, found := b.permissionKeys[level]
+			if !found {
+				add(defaultKey)
+				continue
+			}
+			for _, key := range keys {
+				add(key)
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		add(defaultKey)
+	}
+
+	return
+}
+
 func (b *BIOS) logEphemeralKey(tag string) {
 	pubKey := b.EphemeralPublicKey.String()
 	privKey := b.EphemeralPrivateKey.String()
@@ -199,38 +365,34 @@ func (b *BIOS) logEphemeralKey(tag string) {
 }
 
 func (b *BIOS) RunChainValidation() (bool, error) {
-	bootSeqMap := ActionMap{}
-	bootSeq := []*eos.Action{}
+	expectedActions := map[string]bool{}
+	expectedCount := 0
 
 	for _, step := range b.BootSequence.BootSequence {
-		acts, err := step.Data.Actions(b)
-		if err != nil {
-			return false, fmt.Errorf("validating: getting actions for step %q: %s", step.Op, err)
-		}
-
-		for _, stepAction := range acts {
+		err := b.streamActions(step.Data, func(stepAction *eos.Action) error {
 			if stepAction == nil {
-				continue
+				return nil
 			}
 
-			stepAction.SetToServer(true)
-			data, err := eos.MarshalBinary(stepAction)
+			key, err := actionKey(stepAction)
 			if err != nil {
-				return false, fmt.Errorf("validating: binary marshalling: %s", err)
+				return fmt.Errorf("binary marshalling: %s", err)
 			}
-			key := sha2(data)
 
-			// if _, ok := bootSeqMap[key]; ok {
+			// if _, ok := expectedActions[key]; ok {
 			// 	// TODO: don't fatal here plz :)
 			// 	log.Fatalf("Same action detected twice [%s] with key [%s]\n", stepAction.Name, key)
 			// }
-			bootSeqMap[key] = stepAction
-			bootSeq = append(bootSeq, stepAction)
+			expectedActions[key] = true
+			expectedCount++
+			return nil
+		})
+		if err != nil {
+			return false, fmt.Errorf("validating: getting actions for step %q: %s", step.Op, err)
 		}
-
 	}
 
-	err := b.validateTargetNetwork(bootSeqMap, bootSeq)
+	err := b.validateTargetNetwork(expectedActions, expectedCount)
 	if err != nil {
 		b.Log.Printf("BOOT SEQUENCE VALIDATION FAILED:\n%s", err)
 		return false, nil
@@ -257,14 +419,9 @@ func (b *BIOS) writeAllActionsToDisk() error {
 	defer fl.Close()
 
 	for _, step := range b.BootSequence.BootSequence {
-		acts, err := step.Data.Actions(b)
-		if err != nil {
-			return fmt.Errorf("fetch step %q: %s", step.Op, err)
-		}
-
-		for _, stepAction := range acts {
+		err := b.streamActions(step.Data, func(stepAction *eos.Action) error {
 			if stepAction == nil {
-				continue
+				return nil
 			}
 
 			stepAction.SetToServer(false)
@@ -278,13 +435,71 @@ func (b *BIOS) writeAllActionsToDisk() error {
 				return err
 			}
 			_, _ = fl.Write([]byte("\n"))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("fetch step %q: %s", step.Op, err)
+		}
+	}
+
+	return nil
+}
+
+// streamActions hands each action of `op` to `emit`, in order,
+// without building them all in memory for a `StreamingOperation`.
+func (b *BIOS) streamActions(op Operation, emit func(*eos.Action) error) error {
+	if streamer, ok := op.(StreamingOperation); ok {
+		return streamer.StreamActions(b, emit)
+	}
+
+	acts, err := op.Actions(b)
+	if err != nil {
+		return err
+	}
+
+	return emitActions(emit, acts...)
+}
+
+// streamChunks groups the actions of `op` in transactions like
+// `ChunkifyActions` does, and hands each one to `push` as soon as it
+// is complete.
+func (b *BIOS) streamChunks(op Operation, push func(chunk []*eos.Action) error) error {
+	var currentChunk []*eos.Action
+	err := b.streamActions(op, func(act *eos.Action) error {
+		if act != nil {
+			currentChunk = append(currentChunk, act)
+			return nil
+		}
+
+		if len(currentChunk) == 0 {
+			return nil
 		}
+
+		chunk := currentChunk
+		currentChunk = nil
+		return push(chunk)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(currentChunk) > 0 {
+		return push(currentChunk)
 	}
 
 	return nil
 }
 
-type ActionMap map[string]*eos.Action
+// actionKey identifies an action in blocks, to match what was pushed
+// against what was expected.
+func actionKey(act *eos.Action) (string, error) {
+	act.SetToServer(true)
+	data, err := eos.MarshalBinary(act)
+	if err != nil {
+		return "", err
+	}
+	return sha2(data), nil
+}
 
 type ValidationError struct {
 	Err               error
@@ -350,8 +565,7 @@ func (b *BIOS) pingTargetNetwork() {
 	b.Log.Println(" touchdown!")
 }
 
-func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action) (err error) {
-	expectedActionCount := len(bootSeq)
+func (b *BIOS) validateTargetNetwork(expectedActions map[string]bool, expectedActionCount int) (err error) {
 	validationErrors := make([]error, 0)
 
 	b.pingTargetNetwork()
@@ -398,7 +612,7 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 		}
 
 		if !timeLastNotFound.IsZero() && timeLastNotFound.Before(time.Now().Add(-10*time.Second)) {
-			b.flushMissingActions(seenMap, bootSeq)
+			b.flushMissingActions(seenMap)
 		}
 
 		for _, receipt := range m.Transactions {
@@ -427,7 +641,7 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 				key := sha2(data) // TODO: compute a hash here..
 
 				b.Log.Printf("- Validating action %d/%d [%s::%s]", actionsRead+1, expectedActionCount, act.Account, act.Name)
-				if _, ok := bootSeqMap[key]; !ok {
+				if !expectedActions[key] {
 					validationErrors = append(validationErrors, ValidationError{
 						Err:               errors.New("not found"),
 						BlockNumber:       1, // extract from the block transactionmroot
@@ -447,7 +661,7 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 			}
 		}
 
-		if actionsRead == len(bootSeq) {
+		if actionsRead == expectedActionCount {
 			break
 		}
 
@@ -460,7 +674,7 @@ func (b *BIOS) validateTargetNetwork(bootSeqMap ActionMap, bootSeq []*eos.Action
 	return nil
 }
 
-func (b *BIOS) flushMissingActions(seenMap map[string]bool, bootSeq []*eos.Action) {
+func (b *BIOS) flushMissingActions(seenMap map[string]bool) {
 	fl, err := os.Create("missing_actions.jsonl")
 	if err != nil {
 		fmt.Println("Couldn't write to `missing_actions.jsonl`:", err)
@@ -471,21 +685,49 @@ func (b *BIOS) flushMissingActions(seenMap map[string]bool, bootSeq []*eos.Actio
 	// TODO: print all actions that are still MISSING to `missing_actions.jsonl`.
 	b.Log.Println("Flushing unseen transactions to `missing_actions.jsonl` up until this point.")
 
-	for _, act := range bootSeq {
-		act.SetToServer(true)
-		data, _ := eos.MarshalBinary(act)
-		key := sha2(data)
+	for _, step := range b.BootSequence.BootSequence {
+		err := b.streamActions(step.Data, func(act *eos.Action) error {
+			if act == nil {
+				return nil
+			}
 
-		if !seenMap[key] {
-			act.SetToServer(false)
-			data, _ := json.Marshal(act)
-			fl.Write(data)
-			fl.Write([]byte("\n"))
+			key, _ := actionKey(act)
+			if !seenMap[key] {
+				act.SetToServer(false)
+				data, _ := json.Marshal(act)
+				fl.Write(data)
+				fl.Write([]byte("\n"))
+			}
+			return nil
+		})
+		if err != nil {
+			b.Log.Printf("Couldn't flush missing actions for step %q: %s\n", step.Op, err)
+			return
 		}
 	}
 }
 
-func (b *BIOS) inputGenesisData() (genesis *GenesisJSON) {
+// ReadGenesis gets the genesis JSON of the network to join from `ref`,
+// a local file or an http(s) URL. Without a `ref`, it is prompted for.
+func (b *BIOS) ReadGenesis(ref string) (string, error) {
+	if ref == "" {
+		return b.inputGenesisData(), nil
+	}
+
+	cnt, err := b.downloadRef(ref)
+	if err != nil {
+		return "", fmt.Errorf("reading genesis: %s", err)
+	}
+
+	var genesis *GenesisJSON
+	if err := json.Unmarshal(cnt, &genesis); err != nil {
+		return "", fmt.Errorf("invalid genesis data in %q: %s", ref, err)
+	}
+
+	return string(cnt), nil
+}
+
+func (b *BIOS) inputGenesisData() (genesisJSON string) {
 	b.Log.Println("")
 
 	for {
@@ -496,13 +738,14 @@ func (b *BIOS) inputGenesisData() (genesis *GenesisJSON) {
 			continue
 		}
 
+		var genesis *GenesisJSON
 		err = json.Unmarshal([]byte(genesisData), &genesis)
 		if err != nil {
 			b.Log.Printf("Invalid genesis data: %s\n", err)
 			continue
 		}
 
-		return
+		return strings.TrimSpace(genesisData)
 	}
 }
 
@@ -540,6 +783,20 @@ func (b *BIOS) LoadGenesisFromFile(pubkey string) (string, error) {
 	return string(out), nil
 }
 
+// CoreSymbol is the system token symbol of the target chain, `4,EOS`
+// unless the boot sequence declares a `core_symbol`.
+func (b *BIOS) CoreSymbol() eos.Symbol {
+	if b.BootSequence != nil && b.BootSequence.CoreSymbol != nil {
+		return eos.Symbol(*b.BootSequence.CoreSymbol)
+	}
+	return eos.EOSSymbol
+}
+
+// NewCoreAsset parses `amount` in the core symbol.
+func (b *BIOS) NewCoreAsset(amount string) (eos.Asset, error) {
+	return NewAssetFromString(amount, b.CoreSymbol())
+}
+
 func (b *BIOS) GetContentsCacheRef(filename string) (string, error) {
 	for _, fl := range b.BootSequence.Contents {
 		if fl.Name == filename {
@@ -0,0 +1,30 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eos-bios")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	genesis := `{"initial_timestamp": "2018-06-01T12:00:00", "initial_key": "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", "initial_configuration": {"max_block_net_usage": 1048576}}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "genesis.json"), []byte(genesis), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bad.json"), []byte("not json"), 0644))
+
+	b := &BIOS{}
+
+	out, err := b.ReadGenesis(filepath.Join(dir, "genesis.json"))
+	require.NoError(t, err)
+	assert.Equal(t, genesis, out, "fields unknown to GenesisJSON are kept")
+
+	_, err = b.ReadGenesis(filepath.Join(dir, "bad.json"))
+	assert.Error(t, err)
+}
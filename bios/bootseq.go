@@ -1,31 +1,85 @@
 package bios
 
 import (
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+
+	"github.com/eoscanada/eos-go"
 )
 
 type BootSeq struct {
-	Keys         map[string]string `json:"keys"`
-	Contents     []*ContentRef     `json:"contents"`
-	BootSequence []*OperationType  `json:"boot_sequence"`
+	Vars         map[string]interface{}    `json:"vars"`
+	Profiles     []string                  `json:"profiles"`
+	Keys         map[string]*KeyDefinition `json:"keys"`
+	CoreSymbol   *Symbol                   `json:"core_symbol"`
+	Contents     []*ContentRef             `json:"contents"`
+	BootSequence []*OperationType          `json:"boot_sequence"`
 }
 
-func ReadBootSeq(filename string) (out *BootSeq, err error) {
-	rawBootSeq, err := ioutil.ReadFile(filename)
+// ReadBootSeq reads a boot sequence, with its variables resolved and
+// `overrides` taking precedence over its `vars:`, and its steps
+// expanded with `profiles` active.
+func ReadBootSeq(filename string, overrides map[string]interface{}, profiles []string) (out *BootSeq, err error) {
+	resolved, err := ReadResolvedBootSeq(filename, overrides, profiles)
 	if err != nil {
-		return nil, fmt.Errorf("reading boot seq: %s", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(resolved, &out); err != nil {
+		return nil, fmt.Errorf("parsing boot seq: %s", err)
 	}
 
-	if err := yamlUnmarshal(rawBootSeq, &out); err != nil {
-		return nil, fmt.Errorf("parsing boot seq yaml: %s", err)
+	if err := CheckFeatureOrdering(out.BootSequence); err != nil {
+		return nil, fmt.Errorf("protocol features: %s", err)
 	}
 
 	return
 }
 
+// ReadResolvedBootSeq returns the boot sequence as JSON, composed with
+// its includes and overlays, with its variables resolved and its steps
+// expanded. See `ComposeBootSeq` and `ResolveBootSeq`.
+func ReadResolvedBootSeq(filename string, overrides map[string]interface{}, profiles []string) ([]byte, error) {
+	doc, err := ComposeBootSeq(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading boot seq: %s", err)
+	}
+
+	resolved, err := resolveVars(doc, overrides, profiles)
+	if err != nil {
+		return nil, fmt.Errorf("resolving boot seq: %s", err)
+	}
+
+	return resolved, nil
+}
+
 type ContentRef struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
 	Hash string `json:"hash"`
 }
+
+// Symbol is an `eos.Symbol` written like `4,EOS` in boot sequences.
+type Symbol eos.Symbol
+
+func (s *Symbol) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+
+	symbol, err := eos.StringToSymbol(str)
+	if err != nil {
+		return err
+	}
+	if _, err := symbol.SymbolCode(); err != nil {
+		return fmt.Errorf("symbol %q: %s", str, err)
+	}
+
+	*s = Symbol(symbol)
+	return nil
+}
+
+func (s Symbol) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eos.Symbol(s).String())
+}
@@ -0,0 +1,431 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	yaml2json "github.com/bronze1man/go-yaml2json"
+)
+
+// IncludeRef points to a boot sequence to `include:`. It is written
+// either as a path, relative to the including file, or like a
+// `contents:` entry, with a `url` and an optional sha256 `hash`.
+type IncludeRef struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+func (r *IncludeRef) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		r.URL = path
+		return nil
+	}
+
+	type plain IncludeRef
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// StepOverlay changes the steps of the included boot sequences,
+// targeting them by `id`. Only one of `remove`, `replace`,
+// `insert_before` and `insert_after` is set, and all but `remove`
+// take `steps`.
+type StepOverlay struct {
+	Remove       string                   `json:"remove"`
+	Replace      string                   `json:"replace"`
+	InsertBefore string                   `json:"insert_before"`
+	InsertAfter  string                   `json:"insert_after"`
+	Steps        []map[string]interface{} `json:"steps"`
+}
+
+// ComposeBootSeq reads a boot sequence along with the files it
+// `include:`s, in order. The including file's `vars:`, `keys:` and
+// `contents:` (by name) take precedence over the included ones, its
+// `profiles:` are added to theirs, its `boot_sequence:` steps come
+// after theirs, and its `overlays:` are applied last. Includes are
+// read before variables are resolved, so they can't use variables.
+func ComposeBootSeq(filename string) (map[string]interface{}, error) {
+	c := &composer{loading: map[string]bool{}}
+	return c.load(&IncludeRef{URL: filename}, "")
+}
+
+type composer struct {
+	loading map[string]bool
+}
+
+func (c *composer) load(ref *IncludeRef, parent string) (map[string]interface{}, error) {
+	location := includeLocation(parent, ref.URL)
+	if c.loading[location] {
+		return nil, fmt.Errorf("%q includes itself", location)
+	}
+	c.loading[location] = true
+	defer delete(c.loading, location)
+
+	cnt, err := readInclude(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyHash(cnt, ref.Hash); err != nil {
+		return nil, fmt.Errorf("%q: %s", location, err)
+	}
+
+	jsonCnt, err := yaml2json.Convert(cnt)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", location, err)
+	}
+
+	var doc map[string]interface{}
+	if err := decodeJSON(jsonCnt, &doc); err != nil {
+		return nil, fmt.Errorf("%q: %s", location, err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	var includes []*IncludeRef
+	if err := remarshal(doc["include"], &includes); err != nil {
+		return nil, fmt.Errorf("%q: include: %s", location, err)
+	}
+	var overlays []*StepOverlay
+	if err := remarshal(doc["overlays"], &overlays); err != nil {
+		return nil, fmt.Errorf("%q: overlays: %s", location, err)
+	}
+	delete(doc, "include")
+	delete(doc, "overlays")
+
+	setSources(doc["boot_sequence"], location, listItemLines(cnt, "boot_sequence"))
+	setSources(doc["contents"], location, listItemLines(cnt, "contents"))
+	overlayLines := listItemLines(cnt, "overlays")
+	for idx, overlay := range overlays {
+		for _, step := range overlay.Steps {
+			if idx < len(overlayLines) {
+				step["source"] = fmt.Sprintf("%s:%d", location, overlayLines[idx])
+			}
+		}
+	}
+
+	out := map[string]interface{}{}
+	for _, include := range includes {
+		included, err := c.load(include, location)
+		if err != nil {
+			return nil, fmt.Errorf("include %q: %s", include.URL, err)
+		}
+		if out, err = mergeBootSeqs(out, included); err != nil {
+			return nil, fmt.Errorf("include %q: %s", include.URL, err)
+		}
+	}
+	if out, err = mergeBootSeqs(out, doc); err != nil {
+		return nil, fmt.Errorf("%q: %s", location, err)
+	}
+
+	if len(overlays) != 0 {
+		steps, _ := out["boot_sequence"].([]interface{})
+		for idx, overlay := range overlays {
+			if steps, err = overlay.apply(steps); err != nil {
+				return nil, fmt.Errorf("%q: overlay #%d: %s", location, idx+1, err)
+			}
+		}
+		out["boot_sequence"] = steps
+	}
+
+	if _, err := stepIndex(out["boot_sequence"]); err != nil {
+		return nil, fmt.Errorf("%q: %s", location, err)
+	}
+
+	return out, nil
+}
+
+// includeLocation resolves `ref` relative to the file including it.
+func includeLocation(parent, ref string) string {
+	if refURL, err := url.Parse(ref); err == nil && refURL.Scheme != "" && len(refURL.Scheme) > 1 {
+		return ref
+	}
+	if parent == "" || filepath.IsAbs(ref) {
+		return ref
+	}
+
+	if parentURL, err := url.Parse(parent); err == nil && (parentURL.Scheme == "http" || parentURL.Scheme == "https") {
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return parentURL.ResolveReference(refURL).String()
+	}
+
+	return filepath.Join(filepath.Dir(parent), ref)
+}
+
+func readInclude(location string) ([]byte, error) {
+	destURL, err := url.Parse(location)
+	if err == nil {
+		switch destURL.Scheme {
+		case "http", "https":
+			return downloadHTTPURL(destURL)
+		case "file":
+			return ioutil.ReadFile(destURL.Path)
+		}
+	}
+
+	return ioutil.ReadFile(location)
+}
+
+// mergeBootSeqs lays `top` over `base`.
+func mergeBootSeqs(base, top map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for key, value := range base {
+		out[key] = value
+	}
+
+	for key, value := range top {
+		switch key {
+		case "vars", "keys":
+			merged := map[string]interface{}{}
+			for _, layer := range []interface{}{out[key], value} {
+				if layer == nil {
+					continue
+				}
+				entries, ok := layer.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s: must be a map", key)
+				}
+				for name, entry := range entries {
+					merged[name] = entry
+				}
+			}
+			out[key] = merged
+
+		case "profiles":
+			seen := map[interface{}]bool{}
+			var profiles []interface{}
+			for _, layer := range []interface{}{out[key], value} {
+				if layer == nil {
+					continue
+				}
+				layerProfiles, ok := layer.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("profiles: must be a list")
+				}
+				for _, profile := range layerProfiles {
+					if !seen[profile] {
+						seen[profile] = true
+						profiles = append(profiles, profile)
+					}
+				}
+			}
+			out[key] = profiles
+
+		case "contents":
+			merged, err := mergeContents(out[key], value)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = merged
+
+		case "boot_sequence":
+			var steps []interface{}
+			for _, layer := range []interface{}{out[key], value} {
+				if layer == nil {
+					continue
+				}
+				layerSteps, ok := layer.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("boot_sequence: must be a list of steps")
+				}
+				steps = append(steps, layerSteps...)
+			}
+			out[key] = steps
+
+		default:
+			out[key] = value
+		}
+	}
+
+	return out, nil
+}
+
+// mergeContents replaces the `base` contents by the `top` ones of the
+// same name, and appends the others.
+func mergeContents(base, top interface{}) ([]interface{}, error) {
+	var out []interface{}
+	byName := map[string]int{}
+	for _, layer := range []interface{}{base, top} {
+		if layer == nil {
+			continue
+		}
+		contents, ok := layer.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("contents: must be a list")
+		}
+
+		for _, content := range contents {
+			name := ""
+			if fields, ok := content.(map[string]interface{}); ok {
+				name, _ = fields["name"].(string)
+			}
+
+			if idx, found := byName[name]; found && name != "" {
+				out[idx] = content
+				continue
+			}
+			byName[name] = len(out)
+			out = append(out, content)
+		}
+	}
+	return out, nil
+}
+
+func (o *StepOverlay) apply(steps []interface{}) ([]interface{}, error) {
+	var targets []string
+	for _, target := range []string{o.Remove, o.Replace, o.InsertBefore, o.InsertAfter} {
+		if target != "" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) != 1 {
+		return nil, fmt.Errorf("set exactly one of remove, replace, insert_before or insert_after")
+	}
+	if o.Remove != "" && len(o.Steps) != 0 {
+		return nil, fmt.Errorf("remove doesn't take steps")
+	}
+	if o.Remove == "" && len(o.Steps) == 0 {
+		return nil, fmt.Errorf("steps missing")
+	}
+
+	index, err := stepIndex(steps)
+	if err != nil {
+		return nil, err
+	}
+	idx, found := index[targets[0]]
+	if !found {
+		return nil, fmt.Errorf("no step with id %q", targets[0])
+	}
+
+	var newSteps []interface{}
+	for _, step := range o.Steps {
+		newSteps = append(newSteps, step)
+	}
+
+	var out []interface{}
+	switch {
+	case o.Remove != "":
+		out = append(out, steps[:idx]...)
+		out = append(out, steps[idx+1:]...)
+	case o.Replace != "":
+		out = append(out, steps[:idx]...)
+		out = append(out, newSteps...)
+		out = append(out, steps[idx+1:]...)
+	case o.InsertBefore != "":
+		out = append(out, steps[:idx]...)
+		out = append(out, newSteps...)
+		out = append(out, steps[idx:]...)
+	case o.InsertAfter != "":
+		out = append(out, steps[:idx+1]...)
+		out = append(out, newSteps...)
+		out = append(out, steps[idx+1:]...)
+	}
+	return out, nil
+}
+
+// stepIndex maps the step IDs to their position, and makes sure they
+// are unique.
+func stepIndex(steps interface{}) (map[string]int, error) {
+	list, _ := steps.([]interface{})
+
+	index := map[string]int{}
+	for idx, step := range list {
+		fields, _ := step.(map[string]interface{})
+		id, _ := fields["id"].(string)
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+
+		if _, found := index[id]; found {
+			return nil, fmt.Errorf("step id %q used more than once", id)
+		}
+		index[id] = idx
+	}
+	return index, nil
+}
+
+// setSources records in the `source` of each of `items` the file and
+// line it comes from, for `LintBootSeq` to report.
+func setSources(items interface{}, location string, lines []int) {
+	list, _ := items.([]interface{})
+	if len(list) != len(lines) {
+		return
+	}
+
+	for idx, item := range list {
+		if fields, ok := item.(map[string]interface{}); ok {
+			fields["source"] = fmt.Sprintf("%s:%d", location, lines[idx])
+		}
+	}
+}
+
+// listItemLines returns the line numbers of the items of the block
+// list under the top-level `key:` of a YAML file. Flow lists, like
+// `key: [a, b]`, aren't handled.
+func listItemLines(cnt []byte, key string) (out []int) {
+	lines := strings.Split(string(cnt), "\n")
+
+	start := -1
+	for idx, line := range lines {
+		if strings.HasPrefix(line, key+":") && strings.TrimSpace(stripYAMLComment(line[len(key)+1:])) == "" {
+			start = idx + 1
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+
+	itemIndent := -1
+	for idx := start; idx < len(lines); idx++ {
+		line := lines[idx]
+		content := strings.TrimLeft(line, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+
+		indent := len(line) - len(content)
+		isItem := content == "-" || strings.HasPrefix(content, "- ")
+		if itemIndent == -1 {
+			if !isItem {
+				return nil
+			}
+			itemIndent = indent
+		}
+
+		if indent < itemIndent || (indent == itemIndent && !isItem) {
+			break
+		}
+		if indent == itemIndent {
+			out = append(out, idx+1)
+		}
+	}
+	return out
+}
+
+func stripYAMLComment(s string) string {
+	if idx := strings.Index(s, "#"); idx != -1 {
+		return s[:idx]
+	}
+	return s
+}
+
+// remarshal decodes a generic YAML value into `v`.
+func remarshal(value interface{}, v interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	cnt, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(cnt, v)
+}
@@ -0,0 +1,107 @@
+package bios
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBootSeqFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "eos-bios-compose")
+	require.NoError(t, err)
+
+	for name, cnt := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0777))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(cnt), 0666))
+	}
+	return dir
+}
+
+func TestComposeBootSeq(t *testing.T) {
+	dir := writeBootSeqFiles(t, map[string]string{
+		"base/boot_sequence.yaml": `
+vars:
+  ram: 1024
+keys:
+  ephemeral: ""
+contents:
+- {name: eosio.system.abi, url: https://example.com/v1/eosio.system.abi}
+- {name: snapshot.csv, url: https://example.com/snapshot.csv}
+boot_sequence:
+- {op: system.setram, id: set_ram, data: {max_ram_size: "${ram}"}}
+- {op: system.setprods, id: set_producers}
+- {op: system.resign_accounts, id: resign_accounts}
+`,
+		"testnet.yaml": `
+include:
+- base/boot_sequence.yaml
+vars:
+  ram: 2048
+contents:
+- {name: eosio.system.abi, url: https://example.com/v2/eosio.system.abi}
+overlays:
+- remove: resign_accounts
+- insert_before: set_producers
+  steps:
+  - {op: system.setramrate, id: set_ram_rate}
+- replace: set_ram
+  steps:
+  - {op: system.setram, data: {max_ram_size: "${ram}"}}
+boot_sequence:
+- {op: system.setpriv, id: set_priv}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	bootSeq, err := ReadBootSeq(filepath.Join(dir, "testnet.yaml"), nil, nil)
+	require.NoError(t, err)
+
+	var steps []string
+	for _, step := range bootSeq.BootSequence {
+		steps = append(steps, step.Op+"/"+step.ID)
+	}
+	assert.Equal(t, []string{"system.setram/", "system.setramrate/set_ram_rate", "system.setprods/set_producers", "system.setpriv/set_priv"}, steps)
+	assert.Equal(t, uint64(2048), bootSeq.BootSequence[0].Data.(*OpSetRAM).MaxRAMSize)
+
+	require.Len(t, bootSeq.Contents, 2)
+	assert.Equal(t, "https://example.com/v2/eosio.system.abi", bootSeq.Contents[0].URL)
+	assert.Equal(t, "snapshot.csv", bootSeq.Contents[1].Name)
+}
+
+func TestComposeBootSeqErrors(t *testing.T) {
+	dir := writeBootSeqFiles(t, map[string]string{
+		"base.yaml":      "boot_sequence:\n- {op: system.setprods, id: set_producers}\n",
+		"loop.yaml":      "include: [loop.yaml]\n",
+		"unknown.yaml":   "include: [base.yaml]\noverlays:\n- remove: resign_accounts\n",
+		"twice.yaml":     "include: [base.yaml]\nboot_sequence:\n- {op: system.setprods, id: set_producers}\n",
+		"ambiguous.yaml": "include: [base.yaml]\noverlays:\n- {remove: set_producers, replace: set_producers}\n",
+		"hash.yaml":      "include:\n- {url: base.yaml, hash: abcd}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file      string
+		expectErr string
+	}{
+		{"loop.yaml", `include "loop.yaml": %q includes itself`},
+		{"unknown.yaml", `%q: overlay #1: no step with id "resign_accounts"`},
+		{"twice.yaml", `%q: step id "set_producers" used more than once`},
+		{"ambiguous.yaml", `%q: overlay #1: set exactly one of remove, replace, insert_before or insert_after`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			filename := filepath.Join(dir, test.file)
+			_, err := ComposeBootSeq(filename)
+			assert.EqualError(t, err, fmt.Sprintf(test.expectErr, filename))
+		})
+	}
+
+	_, err := ComposeBootSeq(filepath.Join(dir, "hash.yaml"))
+	assert.Contains(t, err.Error(), `hash in boot sequence ["abcd"] not equal to computed hash`)
+}
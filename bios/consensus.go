@@ -0,0 +1,254 @@
+package bios
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// ContentConsensus is the agreement of the participants on each of
+// the `target_contents` of their discovery files.
+type ContentConsensus struct {
+	Items []*ContentAgreement // sorted by name
+
+	totalScore float64
+}
+
+// ContentAgreement gathers the refs proposed for one content name,
+// each weighted by the trust scores of the participants proposing it.
+type ContentAgreement struct {
+	Name       string
+	Candidates []*ContentCandidate // by decreasing score
+
+	// Missing are the participants who didn't list this content.
+	Missing []eos.AccountName
+}
+
+// ContentCandidate is one ref proposed for a content.
+type ContentCandidate struct {
+	Ref        string
+	Score      float64
+	Supporters []eos.AccountName
+
+	// URL and Hash are where the winners were downloaded from by
+	// `FetchWinners`, and the sha256 of what was downloaded.
+	URL  string
+	Hash string
+}
+
+// ComputeContentConsensus goes through the `target_contents` of all
+// participants of `mesh`. Only the first entry of a given name counts
+// for a participant.
+func ComputeContentConsensus(mesh *Mesh) *ContentConsensus {
+	c := &ContentConsensus{}
+	items := map[string]*ContentAgreement{}
+	candidates := map[string]map[string]*ContentCandidate{}
+
+	for _, peer := range mesh.Peers {
+		c.totalScore += peer.Score
+
+		seen := map[string]bool{}
+		for _, content := range peer.Discovery.TargetContents {
+			if seen[content.Name] {
+				continue
+			}
+			seen[content.Name] = true
+
+			item := items[content.Name]
+			if item == nil {
+				item = &ContentAgreement{Name: content.Name}
+				items[content.Name] = item
+				candidates[content.Name] = map[string]*ContentCandidate{}
+				c.Items = append(c.Items, item)
+			}
+
+			candidate := candidates[content.Name][content.Ref]
+			if candidate == nil {
+				candidate = &ContentCandidate{Ref: content.Ref}
+				candidates[content.Name][content.Ref] = candidate
+				item.Candidates = append(item.Candidates, candidate)
+			}
+
+			candidate.Score += peer.Score
+			candidate.Supporters = append(candidate.Supporters, peer.Account)
+		}
+	}
+
+	for _, item := range c.Items {
+		sort.SliceStable(item.Candidates, func(i, j int) bool {
+			a, b := item.Candidates[i], item.Candidates[j]
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			if len(a.Supporters) != len(b.Supporters) {
+				return len(a.Supporters) > len(b.Supporters)
+			}
+			return a.Ref < b.Ref
+		})
+
+		for _, peer := range mesh.Peers {
+			if !item.listedBy(peer.Account) {
+				item.Missing = append(item.Missing, peer.Account)
+			}
+		}
+	}
+
+	sort.Slice(c.Items, func(i, j int) bool { return c.Items[i].Name < c.Items[j].Name })
+
+	return c
+}
+
+func (a *ContentAgreement) listedBy(account eos.AccountName) bool {
+	for _, candidate := range a.Candidates {
+		for _, supporter := range candidate.Supporters {
+			if supporter == account {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Winner is the ref with the most trust behind it.
+func (a *ContentAgreement) Winner() *ContentCandidate {
+	if len(a.Candidates) == 0 {
+		return nil
+	}
+	return a.Candidates[0]
+}
+
+// Unanimous is true when every participant listed the same ref.
+func (a *ContentAgreement) Unanimous() bool {
+	return len(a.Candidates) == 1 && len(a.Missing) == 0
+}
+
+// Dissenters are the participants who proposed another ref than the
+// winner.
+func (a *ContentAgreement) Dissenters() (out []eos.AccountName) {
+	for _, candidate := range a.Candidates[1:] {
+		out = append(out, candidate.Supporters...)
+	}
+	return
+}
+
+// Agreed is true when the winner of each content has more than half
+// of the total trust behind it.
+func (c *ContentConsensus) Agreed() bool {
+	for _, item := range c.Items {
+		if !c.hasMajority(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ContentConsensus) hasMajority(item *ContentAgreement) bool {
+	winner := item.Winner()
+	return winner != nil && winner.Score > c.totalScore/2
+}
+
+func (c *ContentConsensus) share(candidate *ContentCandidate) float64 {
+	if c.totalScore == 0 {
+		return 0
+	}
+	return 100 * candidate.Score / c.totalScore
+}
+
+// FetchWinners downloads the winning ref of each content having a
+// majority, to record where it was found and its sha256 hash. `/ipfs/`
+// refs are downloaded through `ipfsGateway`.
+func (c *ContentConsensus) FetchWinners(ipfsGateway string) error {
+	for _, item := range c.Items {
+		if !c.hasMajority(item) {
+			continue
+		}
+
+		winner := item.Winner()
+		location := winner.Ref
+		if strings.HasPrefix(location, "/ipfs/") {
+			location = strings.TrimRight(ipfsGateway, "/") + location
+		}
+
+		cnt, err := readInclude(location)
+		if err != nil {
+			return fmt.Errorf("content %q: %s", item.Name, err)
+		}
+
+		h := sha256.Sum256(cnt)
+		winner.URL = location
+		winner.Hash = hex.EncodeToString(h[:])
+	}
+	return nil
+}
+
+// Contents returns the winning ref of each content having a majority,
+// to use as the `contents` of a boot sequence. `FetchWinners` must
+// have run, for them to have a URL and hash.
+func (c *ContentConsensus) Contents() (out []*ContentRef) {
+	for _, item := range c.Items {
+		if c.hasMajority(item) {
+			winner := item.Winner()
+			out = append(out, &ContentRef{Name: item.Name, URL: winner.URL, Hash: winner.Hash})
+		}
+	}
+	return
+}
+
+// WriteContentsYAML writes the `contents:` block of a
+// `boot_sequence.yaml` out of `Contents`.
+func (c *ContentConsensus) WriteContentsYAML(w io.Writer) {
+	fmt.Fprintln(w, "contents:")
+	for _, item := range c.Items {
+		if !c.hasMajority(item) {
+			continue
+		}
+		winner := item.Winner()
+
+		fmt.Fprintf(w, "  - name: %q\n", item.Name)
+		fmt.Fprintf(w, "    url: %q\n", winner.URL)
+		fmt.Fprintf(w, "    hash: %q\n", winner.Hash)
+		fmt.Fprintf(w, "    comment: %q\n", fmt.Sprintf("%s, agreed by %d participants, %.1f%% of trust", winner.Ref, len(winner.Supporters), c.share(winner)))
+		fmt.Fprintln(w, "")
+	}
+}
+
+// WriteReport prints, for each content, whether everyone agrees and
+// who dissents.
+func (c *ContentConsensus) WriteReport(w io.Writer) {
+	for _, item := range c.Items {
+		winner := item.Winner()
+
+		status := "AGREED"
+		switch {
+		case item.Unanimous():
+			status = "UNANIMOUS"
+		case !c.hasMajority(item):
+			status = "NO MAJORITY"
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", item.Name, status)
+		fmt.Fprintf(w, "  winner: %s (%d participants, %.1f%% of trust)\n", winner.Ref, len(winner.Supporters), c.share(winner))
+		for _, candidate := range item.Candidates[1:] {
+			fmt.Fprintf(w, "  dissent: %s (%.1f%% of trust) by %s\n", candidate.Ref, c.share(candidate), accountList(candidate.Supporters))
+		}
+		if len(item.Missing) != 0 {
+			fmt.Fprintf(w, "  missing from: %s\n", accountList(item.Missing))
+		}
+	}
+}
+
+func accountList(accounts []eos.AccountName) string {
+	out := ""
+	for idx, account := range accounts {
+		if idx != 0 {
+			out += ", "
+		}
+		out += string(account)
+	}
+	return out
+}
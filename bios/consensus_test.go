@@ -0,0 +1,96 @@
+package bios
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentConsensus(t *testing.T) {
+	discovery := func(account string, contents ...string) *disco.Discovery {
+		d := &disco.Discovery{
+			SeedNetworkAccountName: eos.AccountName(account),
+			SeedNetworkPeers: []*disco.PeerLink{
+				{Account: "alice", Weight: 10},
+				{Account: "bob", Weight: 10},
+				{Account: "carol", Weight: 10},
+			},
+		}
+		for i := 0; i < len(contents); i += 2 {
+			d.TargetContents = append(d.TargetContents, disco.ContentRef{Name: contents[i], Ref: contents[i+1]})
+		}
+		return d
+	}
+
+	mesh, err := NewMesh([]*disco.Discovery{
+		discovery("alice", "eosio.system.wasm", "/ipfs/Qmsystem", "snapshot.csv", "/ipfs/Qmsnap1"),
+		discovery("bob", "eosio.system.wasm", "/ipfs/Qmsystem", "snapshot.csv", "/ipfs/Qmsnap2", "snapshot.csv", "/ipfs/Qmignored"),
+		discovery("carol", "eosio.system.wasm", "/ipfs/Qmsystem", "snapshot.csv", "/ipfs/Qmsnap1", "eosio.msig.wasm", "/ipfs/Qmmsig"),
+	})
+	require.NoError(t, err)
+
+	consensus := ComputeContentConsensus(mesh)
+	require.Len(t, consensus.Items, 3)
+
+	msig, system, snapshot := consensus.Items[0], consensus.Items[1], consensus.Items[2]
+
+	assert.Equal(t, "eosio.system.wasm", system.Name)
+	assert.True(t, system.Unanimous())
+	assert.Nil(t, system.Dissenters())
+
+	assert.Equal(t, "/ipfs/Qmsnap1", snapshot.Winner().Ref)
+	assert.Equal(t, []eos.AccountName{"alice", "carol"}, snapshot.Winner().Supporters)
+	assert.Equal(t, []eos.AccountName{"bob"}, snapshot.Dissenters())
+	assert.Len(t, snapshot.Candidates, 2)
+
+	assert.Equal(t, []eos.AccountName{"alice", "bob"}, msig.Missing)
+	assert.False(t, consensus.Agreed(), "msig is only backed by a third of the trust")
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ipfs/Qmsystem", "/ipfs/Qmsnap1":
+			fmt.Fprintf(w, "content of %s", r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer gateway.Close()
+
+	require.NoError(t, consensus.FetchWinners(gateway.URL))
+
+	// eosio.msig.wasm has no majority, it is left out.
+	assert.Equal(t, []*ContentRef{
+		{Name: "eosio.system.wasm", URL: gateway.URL + "/ipfs/Qmsystem", Hash: "6777c707299a086e3e5cb1209078c41b97baf235b06e59cc356601901dcb8d6f"},
+		{Name: "snapshot.csv", URL: gateway.URL + "/ipfs/Qmsnap1", Hash: "6f21c88cb113191b4f39c25d95db65478d5f414ca665ded902606c84777a7e5b"},
+	}, consensus.Contents())
+
+	buf := &bytes.Buffer{}
+	consensus.WriteContentsYAML(buf)
+
+	var bootSeq *BootSeq
+	require.NoError(t, yamlUnmarshal(buf.Bytes(), &bootSeq))
+	assert.Equal(t, consensus.Contents(), bootSeq.Contents)
+
+	// The written contents download and verify, as `boot` does.
+	cachePath, err := ioutil.TempDir("", "eos-bios-consensus")
+	require.NoError(t, err)
+	defer os.RemoveAll(cachePath)
+
+	b := &BIOS{CachePath: cachePath, BootSequence: bootSeq}
+	require.NoError(t, b.DownloadReferences())
+	cnt, err := b.ReadFromCache(gateway.URL + "/ipfs/Qmsnap1")
+	require.NoError(t, err)
+	assert.Equal(t, "content of /ipfs/Qmsnap1", string(cnt))
+
+	gateway.Close()
+	assert.Error(t, ComputeContentConsensus(mesh).FetchWinners(gateway.URL))
+}
@@ -55,14 +55,8 @@ func (b *BIOS) DownloadURL(ref string, hash string) error {
 		return err
 	}
 
-	if hash != "" {
-		h := sha256.New()
-		_, _ = h.Write(cnt)
-		contentHash := hex.EncodeToString(h.Sum(nil))
-
-		if contentHash != hash {
-			return fmt.Errorf("hash in boot sequence [%q] not equal to computed hash on downloaded file [%q]", hash, contentHash)
-		}
+	if err := verifyHash(cnt, hash); err != nil {
+		return err
 	}
 
 	b.Log.Printf("Caching content from %q.\n", ref)
@@ -90,7 +84,7 @@ func (b *BIOS) downloadRef(ref string) ([]byte, error) {
 	case "file":
 		return b.downloadFileURL(destURL)
 	case "http", "https":
-		return b.downloadHTTPURL(destURL)
+		return downloadHTTPURL(destURL)
 	default:
 		return nil, fmt.Errorf("don't know how to handle scheme %q (from ref %q)", destURL.Scheme, destURL)
 	}
@@ -105,7 +99,7 @@ func (b *BIOS) downloadFileURL(destURL *url.URL) ([]byte, error) {
 	return []byte{}, nil
 }
 
-func (b *BIOS) downloadHTTPURL(destURL *url.URL) ([]byte, error) {
+func downloadHTTPURL(destURL *url.URL) ([]byte, error) {
 	req, err := http.NewRequest("GET", destURL.String(), nil)
 	if err != nil {
 		return nil, err
@@ -132,6 +126,23 @@ func (b *BIOS) downloadHTTPURL(destURL *url.URL) ([]byte, error) {
 	return cnt, nil
 }
 
+// verifyHash checks `cnt` against the sha256 `hash` of a boot
+// sequence, when one is given.
+func verifyHash(cnt []byte, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	h := sha256.New()
+	_, _ = h.Write(cnt)
+	contentHash := hex.EncodeToString(h.Sum(nil))
+
+	if contentHash != hash {
+		return fmt.Errorf("hash in boot sequence [%q] not equal to computed hash on downloaded file [%q]", hash, contentHash)
+	}
+	return nil
+}
+
 func (b *BIOS) writeToCache(ref string, content []byte) error {
 	fileName := replaceAllWeirdities(ref)
 	return ioutil.WriteFile(filepath.Join(b.CachePath, fileName), content, 0666)
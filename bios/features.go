@@ -0,0 +1,131 @@
+package bios
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// PreactivateFeature is the protocol feature scheduled through the
+// producer API, which enables the `activate` action for all others.
+const PreactivateFeature = "PREACTIVATE_FEATURE"
+
+// ProtocolFeatures are the digests of the builtin protocol features
+// of nodeos, by codename. They are the same on every chain.
+var ProtocolFeatures = map[string]string{
+	"PREACTIVATE_FEATURE":              "0ec7e080177b2c02b278d5088611686b49d739925a92d9bfcacd7fc6b74053bd",
+	"ONLY_LINK_TO_EXISTING_PERMISSION": "1a99a59d87e06e09ec5b028a9cbb7749b4a5ad8819004365d02dc4379a8b7241",
+	"FORWARD_SETCODE":                  "2652f5f96006294109b3dd0bbde63693f55324af452b799ee137a81a905eed25",
+	"WTMSIG_BLOCK_SIGNATURES":          "299dcb6af692324b899b39f16d5a530a33062804e41f09dc97e9f156b4476707",
+	"REPLACE_DEFERRED":                 "ef43112c6543b88db2283a2e077278c315ae2c84719a8b25f25cc88565fbea99",
+	"NO_DUPLICATE_DEFERRED_ID":         "4a90c00d55454dc5b059055ca213579c6ea856967712a56017487886a4d4cc0f",
+	"RAM_RESTRICTIONS":                 "4e7bf348da00a945489b2a681749eb56f5de00b900014e137ddae39f48f69d67",
+	"WEBAUTHN_KEY":                     "4fca8bd82bbd181e714e283f83e1b45d95ca5af40fb89ad3977b653c448f78c2",
+	"DISALLOW_EMPTY_PRODUCER_SCHEDULE": "68dcaa34c0517d19666e6b33add67351d8c5f69e999ca1e37931bc410a297428",
+	"ONLY_BILL_FIRST_AUTHORIZER":       "8ba52fe7a3956c5cd3a656a3174b931d3bb2abb45578befc59f283ecd816a405",
+	"RESTRICT_ACTION_TO_SELF":          "ad9e3d8f650687709fd68f4b90b41f7d825a365b02c23a636cef88ac2ac00c43",
+	"FIX_LINKAUTH_RESTRICTION":         "e0fb64b1085cc5538970158d05a009c24e276fb94e1a0bf6a528b48fbc4ff526",
+	"GET_SENDER":                       "f0af56d2c5a48d60a4a5b5c903edfb7db3a736a94ed589d0b797df33ff9d3e1d",
+}
+
+// activationOrder is the order `system.activate` with `all` uses.
+// `WTMSIG_BLOCK_SIGNATURES` changes the block format, so it goes
+// last.
+var activationOrder = []string{
+	"ONLY_LINK_TO_EXISTING_PERMISSION",
+	"FORWARD_SETCODE",
+	"REPLACE_DEFERRED",
+	"NO_DUPLICATE_DEFERRED_ID",
+	"RAM_RESTRICTIONS",
+	"WEBAUTHN_KEY",
+	"DISALLOW_EMPTY_PRODUCER_SCHEDULE",
+	"ONLY_BILL_FIRST_AUTHORIZER",
+	"RESTRICT_ACTION_TO_SELF",
+	"FIX_LINKAUTH_RESTRICTION",
+	"GET_SENDER",
+	"WTMSIG_BLOCK_SIGNATURES",
+}
+
+// FeatureDigest resolves a protocol feature codename, or takes a
+// digest in hex as is for features unknown to eos-bios.
+func FeatureDigest(feature string) (eos.Checksum256, error) {
+	digest, found := ProtocolFeatures[strings.ToUpper(feature)]
+	if !found {
+		digest = feature
+	}
+
+	out, err := hex.DecodeString(digest)
+	if err != nil || len(out) != 32 {
+		return nil, fmt.Errorf("unknown protocol feature %q, use a codename or a 64 characters hex digest", feature)
+	}
+	return eos.Checksum256(out), nil
+}
+
+func featureName(digest eos.Checksum256) string {
+	for name, known := range ProtocolFeatures {
+		if known == digest.String() {
+			return name
+		}
+	}
+	return digest.String()
+}
+
+// CheckFeatureOrdering catches the protocol feature steps nodeos
+// would reject: activations before `PREACTIVATE_FEATURE` was
+// scheduled, or before `eosio` got a contract providing `activate`
+// after that, `PREACTIVATE_FEATURE` going through `system.activate`,
+// and features activated twice.
+func CheckFeatureOrdering(steps []*OperationType) error {
+	preactivated := false
+	eosioCodeSet := false
+	activated := map[string]int{}
+
+	for idx, step := range steps {
+		switch op := step.Data.(type) {
+		case *OpPreactivateFeature:
+			for _, feature := range op.features() {
+				digest, err := FeatureDigest(feature)
+				if err != nil {
+					return fmt.Errorf("step %d: %s", idx+1, err)
+				}
+				if featureName(digest) == PreactivateFeature {
+					preactivated = true
+				}
+			}
+
+		case *OpSetCode:
+			// Contracts providing `activate` can only be set once
+			// `PREACTIVATE_FEATURE` is scheduled.
+			if op.Account == AN("eosio") {
+				eosioCodeSet = preactivated
+			}
+
+		case *OpActivateFeature:
+			if !preactivated {
+				return fmt.Errorf("step %d: activating features before scheduling %s with producer_api.preactivate", idx+1, PreactivateFeature)
+			}
+			if !eosioCodeSet {
+				return fmt.Errorf("step %d: activating features before setting a contract with the `activate` action on eosio, after producer_api.preactivate", idx+1)
+			}
+
+			digests, err := op.digests()
+			if err != nil {
+				return fmt.Errorf("step %d: %s", idx+1, err)
+			}
+			for _, digest := range digests {
+				name := featureName(digest)
+				if name == PreactivateFeature {
+					return fmt.Errorf("step %d: %s is scheduled with producer_api.preactivate, not activated", idx+1, PreactivateFeature)
+				}
+				if previous, found := activated[name]; found {
+					return fmt.Errorf("step %d: %s already activated at step %d", idx+1, name, previous)
+				}
+				activated[name] = idx + 1
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package bios
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureDigest(t *testing.T) {
+	digest, err := FeatureDigest("WTMSIG_BLOCK_SIGNATURES")
+	require.NoError(t, err)
+	assert.Equal(t, "299dcb6af692324b899b39f16d5a530a33062804e41f09dc97e9f156b4476707", digest.String())
+
+	digest, err = FeatureDigest("get_sender")
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolFeatures["GET_SENDER"], digest.String())
+
+	digest, err = FeatureDigest("5443fcf88330c586bc0e5f3dee10e7f63c76c00249c87fe4fbf7f38c082006b4")
+	require.NoError(t, err)
+	assert.Equal(t, "5443fcf88330c586bc0e5f3dee10e7f63c76c00249c87fe4fbf7f38c082006b4", digest.String())
+
+	_, err = FeatureDigest("NOT_A_FEATURE")
+	assert.EqualError(t, err, `unknown protocol feature "NOT_A_FEATURE", use a codename or a 64 characters hex digest`)
+
+	for _, name := range activationOrder {
+		assert.Contains(t, ProtocolFeatures, name)
+	}
+	assert.Len(t, activationOrder, len(ProtocolFeatures)-1)
+}
+
+func TestCheckFeatureOrdering(t *testing.T) {
+	tests := []struct {
+		steps string
+		err   string
+	}{
+		{`
+- op: producer_api.preactivate
+- op: system.setcode
+  data: {account: eosio, contract_name_ref: eosio.bios}
+- op: system.activate
+  data: {all: true}
+`, ""},
+		{`
+- op: system.setcode
+  data: {account: eosio, contract_name_ref: eosio.bios}
+- op: system.activate
+  data: {features: [GET_SENDER]}
+`, "step 2: activating features before scheduling PREACTIVATE_FEATURE with producer_api.preactivate"},
+		{`
+- op: system.setcode
+  data: {account: eosio, contract_name_ref: eosio.bios}
+- op: producer_api.preactivate
+- op: system.activate
+  data: {features: [GET_SENDER]}
+`, "step 3: activating features before setting a contract with the `activate` action on eosio, after producer_api.preactivate"},
+		{`
+- op: producer_api.preactivate
+- op: system.setcode
+  data: {account: eosio, contract_name_ref: eosio.bios}
+- op: system.activate
+  data: {features: [PREACTIVATE_FEATURE]}
+`, "step 3: PREACTIVATE_FEATURE is scheduled with producer_api.preactivate, not activated"},
+		{`
+- op: producer_api.preactivate
+- op: system.setcode
+  data: {account: eosio, contract_name_ref: eosio.bios}
+- op: system.activate
+  data: {features: [GET_SENDER]}
+- op: system.activate
+  data: {all: true}
+`, "step 4: GET_SENDER already activated at step 3"},
+	}
+
+	for idx, test := range tests {
+		var bootSeq *BootSeq
+		require.NoError(t, yamlUnmarshal([]byte("boot_sequence:"+test.steps), &bootSeq), "idx=%d", idx)
+
+		err := CheckFeatureOrdering(bootSeq.BootSequence)
+		if test.err == "" {
+			assert.NoError(t, err, "idx=%d", idx)
+		} else {
+			assert.EqualError(t, err, test.err, "idx=%d", idx)
+		}
+	}
+}
+
+func TestOpPreactivateFeature(t *testing.T) {
+	var scheduled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/producer/schedule_protocol_feature_activations", r.URL.Path)
+
+		var req struct {
+			ProtocolFeaturesToActivate []string `json:"protocol_features_to_activate"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		scheduled = append(scheduled, req.ProtocolFeaturesToActivate...)
+
+		w.Write([]byte(`{"result": "ok"}`))
+	}))
+	defer server.Close()
+
+	b := &BIOS{TargetNetAPI: eos.New(server.URL)}
+
+	op := &OpPreactivateFeature{}
+	acts, err := op.Actions(b)
+	require.NoError(t, err)
+	assert.Len(t, acts, 0)
+
+	require.NoError(t, op.Run(b))
+	assert.Equal(t, []string{ProtocolFeatures[PreactivateFeature]}, scheduled)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`producer_api_plugin not enabled`))
+	}))
+	defer failing.Close()
+
+	err = (&OpPreactivateFeature{ProducerAPIURL: failing.URL}).Run(b)
+	assert.EqualError(t, err, "scheduling protocol features: status 404: producer_api_plugin not enabled")
+}
+
+func TestOpActivateFeature(t *testing.T) {
+	acts, err := (&OpActivateFeature{All: true}).Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, len(activationOrder))
+
+	last := acts[len(acts)-1]
+	assert.Equal(t, eos.ActionName("activate"), last.Name)
+	assert.Equal(t, ProtocolFeatures["WTMSIG_BLOCK_SIGNATURES"], last.ActionData.Data.(Activate).FeatureDigest.String())
+
+	_, err = (&OpActivateFeature{}).Actions(&BIOS{})
+	assert.EqualError(t, err, "no features to activate, list some or set `all`")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 func (b *BIOS) DispatchBootNode(genesisJSON, publicKey, privateKey string) error {
@@ -14,6 +15,16 @@ func (b *BIOS) DispatchBootNode(genesisJSON, publicKey, privateKey string) error
 	}, nil)
 }
 
+// DispatchJoinNetwork runs the `join_network` hook, which starts a
+// node with `genesisJSON`, connecting to the space-separated
+// `p2pAddresses`.
+func (b *BIOS) DispatchJoinNetwork(genesisJSON string, p2pAddresses []string) error {
+	return b.dispatch("join_network", []string{
+		genesisJSON,
+		strings.Join(p2pAddresses, " "),
+	}, nil)
+}
+
 // dispatch to both exec calls, and remote web hooks.
 func (b *BIOS) dispatch(hookName string, args []string, f func() error) error {
 	b.Log.Printf("---- BEGIN HOOK %q ----\n", hookName)
@@ -0,0 +1,259 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// keyRefPrefix marks a reference to a key of the `keys:` section, like
+// `pubkey: key:producers`.
+const keyRefPrefix = "key:"
+
+// KeyDefinition is a named key of the `keys:` section. It is written
+// as a public key, a private key, or a map giving the private key
+// itself, in a file or in an environment variable. A `public_key`
+// next to a private key from a file or the environment lets the boot
+// sequence be read, for validation, without the private key.
+//
+// The private keys are loaded to sign for the `permissions` listed,
+// as `account@permission`, and for the accounts created with the key.
+// A key without a value, like `producers:`, has no public or private
+// key: its definition is nil or empty.
+type KeyDefinition struct {
+	PublicKey      string   `json:"public_key"`
+	PrivateKey     string   `json:"private_key"`
+	PrivateKeyFile string   `json:"private_key_file"`
+	PrivateKeyEnv  string   `json:"private_key_env"`
+	Permissions    []string `json:"permissions"`
+}
+
+func (d *KeyDefinition) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		key = strings.TrimSpace(key)
+		if strings.HasPrefix(key, "EOS") || strings.HasPrefix(key, "PUB_") {
+			d.PublicKey = key
+		} else {
+			d.PrivateKey = key
+		}
+		return nil
+	}
+
+	type plain KeyDefinition
+	return json.Unmarshal(data, (*plain)(d))
+}
+
+// Check makes sure the definition gives one private key source at
+// most, and that the literal keys are valid.
+func (d *KeyDefinition) Check() error {
+	if d == nil {
+		return fmt.Errorf("no public or private key")
+	}
+
+	sources := 0
+	for _, source := range []string{d.PrivateKey, d.PrivateKeyFile, d.PrivateKeyEnv} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("set only one of private_key, private_key_file and private_key_env")
+	}
+	if sources == 0 && d.PublicKey == "" {
+		return fmt.Errorf("no public or private key")
+	}
+
+	if d.PublicKey != "" {
+		if _, err := ecc.NewPublicKey(d.PublicKey); err != nil {
+			return fmt.Errorf("public_key: %s", err)
+		}
+	}
+	if d.PrivateKey != "" {
+		if _, err := ecc.NewPrivateKey(d.PrivateKey); err != nil {
+			return fmt.Errorf("private_key: %s", err)
+		}
+	}
+	for _, permission := range d.Permissions {
+		if _, err := eos.NewPermissionLevel(permission); err != nil {
+			return fmt.Errorf("permissions: %s", err)
+		}
+	}
+	if len(d.Permissions) != 0 && sources == 0 {
+		return fmt.Errorf("permissions need a private key to sign with")
+	}
+
+	return nil
+}
+
+// LoadPrivateKey reads the private key, or returns nil when there is
+// none.
+func (d *KeyDefinition) LoadPrivateKey() (*ecc.PrivateKey, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	wif := d.PrivateKey
+	switch {
+	case d.PrivateKeyFile != "":
+		cnt, err := ioutil.ReadFile(d.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("private_key_file: %s", err)
+		}
+		wif = string(cnt)
+	case d.PrivateKeyEnv != "":
+		wif = os.Getenv(d.PrivateKeyEnv)
+		if wif == "" {
+			return nil, fmt.Errorf("private_key_env: %s isn't set", d.PrivateKeyEnv)
+		}
+	case wif == "":
+		return nil, nil
+	}
+
+	privKey, err := ecc.NewPrivateKey(strings.TrimSpace(wif))
+	if err != nil {
+		return nil, err
+	}
+
+	if d.PublicKey != "" && privKey.PublicKey().String() != d.PublicKey {
+		return nil, fmt.Errorf("private key doesn't match public_key %s", d.PublicKey)
+	}
+
+	return privKey, nil
+}
+
+// LoadPublicKey returns `public_key`, or the public key of the private
+// key.
+func (d *KeyDefinition) LoadPublicKey() (ecc.PublicKey, error) {
+	if d != nil && d.PublicKey != "" {
+		return ecc.NewPublicKey(d.PublicKey)
+	}
+
+	privKey, err := d.LoadPrivateKey()
+	if err != nil {
+		return ecc.PublicKey{}, err
+	}
+	if privKey == nil {
+		return ecc.PublicKey{}, fmt.Errorf("no public or private key")
+	}
+	return privKey.PublicKey(), nil
+}
+
+// PublicKey resolves a public key of a step: `ephemeral`, `key:name`
+// of the `keys:` section, or a literal public key.
+func (b *BIOS) PublicKey(ref string) (ecc.PublicKey, error) {
+	if ref == "ephemeral" {
+		return b.EphemeralPublicKey, nil
+	}
+	if !strings.HasPrefix(ref, keyRefPrefix) {
+		return ecc.NewPublicKey(ref)
+	}
+
+	name := strings.TrimPrefix(ref, keyRefPrefix)
+	if name == "ephemeral" {
+		return b.EphemeralPublicKey, nil
+	}
+
+	if pubKey, found := b.namedKeys[name]; found {
+		return pubKey, nil
+	}
+
+	var def *KeyDefinition
+	found := false
+	if b.BootSequence != nil {
+		def, found = b.BootSequence.Keys[name]
+	}
+	if !found {
+		return ecc.PublicKey{}, fmt.Errorf("no key %q in keys", name)
+	}
+
+	pubKey, err := def.LoadPublicKey()
+	if err != nil {
+		return ecc.PublicKey{}, fmt.Errorf("key %q: %s", name, err)
+	}
+
+	if b.namedKeys == nil {
+		b.namedKeys = map[string]ecc.PublicKey{}
+	}
+	b.namedKeys[name] = pubKey
+
+	return pubKey, nil
+}
+
+// loadKeys imports the private keys of the `keys:` section in the
+// signer, to sign for their `permissions`.
+func (b *BIOS) loadKeys() error {
+	var names []string
+	for name := range b.BootSequence.Keys {
+		if name != "ephemeral" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := b.BootSequence.Keys[name]
+		if err := def.Check(); err != nil {
+			return fmt.Errorf("key %q: %s", name, err)
+		}
+
+		privKey, err := def.LoadPrivateKey()
+		if err != nil {
+			return fmt.Errorf("key %q: %s", name, err)
+		}
+		if privKey == nil {
+			continue
+		}
+
+		if err := b.TargetNetAPI.Signer.ImportPrivateKey(privKey.String()); err != nil {
+			return fmt.Errorf("importing key %q: %s", name, err)
+		}
+		if b.privateKeyNames == nil {
+			b.privateKeyNames = map[string]bool{}
+		}
+		b.privateKeyNames[name] = true
+
+		for _, permission := range def.Permissions {
+			level, _ := eos.NewPermissionLevel(permission)
+			b.setPermissionKey(level, privKey.PublicKey())
+		}
+
+		b.Log.Printf("Loaded private key %q (%s)\n", name, privKey.PublicKey())
+	}
+
+	return nil
+}
+
+// signWithKey makes `ref` sign for the owner and active permissions of
+// the `account` it controls, when it is a key of the `keys:` section
+// with a private key.
+func (b *BIOS) signWithKey(account eos.AccountName, ref string) {
+	name := strings.TrimPrefix(ref, keyRefPrefix)
+	if !strings.HasPrefix(ref, keyRefPrefix) || !b.privateKeyNames[name] {
+		return
+	}
+
+	pubKey, err := b.PublicKey(ref)
+	if err != nil {
+		return
+	}
+	b.setPermissionKey(eos.PermissionLevel{Actor: account, Permission: PN("owner")}, pubKey)
+	b.setPermissionKey(eos.PermissionLevel{Actor: account, Permission: PN("active")}, pubKey)
+}
+
+func (b *BIOS) setPermissionKey(level eos.PermissionLevel, pubKey ecc.PublicKey) {
+	if b.permissionKeys == nil {
+		b.permissionKeys = map[eos.PermissionLevel][]ecc.PublicKey{}
+	}
+	b.permissionKeys[level] = []ecc.PublicKey{pubKey}
+}
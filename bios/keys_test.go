@@ -0,0 +1,109 @@
+package bios
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedKeys(t *testing.T) {
+	ephemeral, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	producers, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	treasury, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	watcher, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	require.NoError(t, os.Setenv("EOS_BIOS_TEST_TREASURY_KEY", treasury.String()))
+	defer os.Unsetenv("EOS_BIOS_TEST_TREASURY_KEY")
+
+	var bootSeq *BootSeq
+	require.NoError(t, yamlUnmarshal([]byte(`
+keys:
+  producers: `+producers.String()+`
+  watcher: `+watcher.PublicKey().String()+`
+  treasury:
+    public_key: `+treasury.PublicKey().String()+`
+    private_key_env: EOS_BIOS_TEST_TREASURY_KEY
+    permissions: [eosio.saving@active]
+`), &bootSeq))
+
+	api := eos.New("http://localhost:1")
+	api.SetSigner(eos.NewKeyBag())
+	b := &BIOS{TargetNetAPI: api, BootSequence: bootSeq, EphemeralPublicKey: ephemeral.PublicKey()}
+	require.NoError(t, b.loadKeys())
+
+	for ref, expected := range map[string]ecc.PublicKey{
+		"ephemeral":     ephemeral.PublicKey(),
+		"key:producers": producers.PublicKey(),
+		"key:watcher":   watcher.PublicKey(),
+		"key:treasury":  treasury.PublicKey(),
+	} {
+		pubKey, err := b.PublicKey(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, expected.String(), pubKey.String(), ref)
+	}
+
+	_, err = b.PublicKey("key:nope")
+	assert.EqualError(t, err, `no key "nope" in keys`)
+
+	availableKeys, err := api.Signer.AvailableKeys()
+	require.NoError(t, err)
+	assert.Len(t, availableKeys, 2)
+
+	acts, err := (&OpNewAccount{Creator: AN("eosio"), NewAccount: AN("eosbarbados1"), Pubkey: "key:producers"}).Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	_, err = (&OpNewAccount{Creator: AN("eosio"), NewAccount: AN("watcher"), Pubkey: "key:watcher"}).Actions(b)
+	require.NoError(t, err)
+
+	tx := &eos.Transaction{Actions: []*eos.Action{
+		{Authorization: []eos.PermissionLevel{{Actor: AN("eosbarbados1"), Permission: PN("active")}}},
+		{Authorization: []eos.PermissionLevel{{Actor: AN("eosio.saving"), Permission: PN("active")}}},
+		{Authorization: []eos.PermissionLevel{{Actor: AN("watcher"), Permission: PN("active")}}},
+	}}
+	var required []string
+	for _, key := range b.requiredKeys(tx, ephemeral.PublicKey()) {
+		required = append(required, key.String())
+	}
+	assert.Equal(t, []string{producers.PublicKey().String(), treasury.PublicKey().String(), ephemeral.PublicKey().String()}, required)
+}
+
+func TestKeyDefinitionCheck(t *testing.T) {
+	tests := []struct {
+		def       *KeyDefinition
+		expectErr string
+	}{
+		{&KeyDefinition{}, "no public or private key"},
+		{&KeyDefinition{PrivateKeyFile: "a.key", PrivateKeyEnv: "KEY"}, "set only one of private_key, private_key_file and private_key_env"},
+		{&KeyDefinition{PublicKey: "EOS123"}, "public_key: invalid format"},
+		{&KeyDefinition{PublicKey: "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", Permissions: []string{"eosio@active"}}, "permissions need a private key to sign with"},
+	}
+
+	for _, test := range tests {
+		assert.EqualError(t, test.def.Check(), test.expectErr)
+	}
+}
+
+func TestKeysWithoutValue(t *testing.T) {
+	var bootSeq *BootSeq
+	require.NoError(t, yamlUnmarshal([]byte("keys:\n  producers:\n  ephemeral:\n"), &bootSeq))
+	require.Contains(t, bootSeq.Keys, "producers")
+
+	b := &BIOS{BootSequence: bootSeq}
+	assert.EqualError(t, b.loadKeys(), `key "producers": no public or private key`)
+	_, err := b.PublicKey("key:producers")
+	assert.EqualError(t, err, `key "producers": no public or private key`)
+	assert.EqualError(t, b.setEphemeralKeypair(), "the ephemeral key needs a private key")
+
+	def := &KeyDefinition{}
+	require.NoError(t, json.Unmarshal([]byte("null"), def))
+	assert.EqualError(t, def.Check(), "no public or private key")
+}
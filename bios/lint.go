@@ -0,0 +1,349 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// LintIssue is a problem found by `LintBootSeq`, with the `file:line`
+// it comes from when it is known.
+type LintIssue struct {
+	Source  string
+	Message string
+}
+
+func (i *LintIssue) String() string {
+	if i.Source == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Source, i.Message)
+}
+
+// dataOptional are the operations that work without `data`.
+var dataOptional = map[string]bool{
+	"system.setparams":           true,
+	"system.init":                true,
+	"snapshot.create_accounts":   true,
+	"snapshot.load_unregistered": true,
+	"producer_api.preactivate":   true,
+}
+
+// publicKeyFields are the `data` fields holding a public key,
+// `ephemeral` or a `key:` of the `keys:` section. An empty
+// `block_signing_key` is the ephemeral key.
+var publicKeyFields = map[string]bool{
+	"pubkey":            true,
+	"key":               true,
+	"block_signing_key": true,
+}
+
+var bootSeqSections = map[string]bool{
+	"vars":          true,
+	"profiles":      true,
+	"keys":          true,
+	"core_symbol":   true,
+	"contents":      true,
+	"boot_sequence": true,
+}
+
+// LintBootSeq reads a boot sequence like `ReadBootSeq`, and checks it
+// strictly: unknown fields, missing `data`, invalid account names and
+// keys, unhashed contents and contracts missing their `.wasm` or
+// `.abi`. An error is returned when the boot sequence can't be read at
+// all.
+func LintBootSeq(filename string, overrides map[string]interface{}, profiles []string) ([]*LintIssue, error) {
+	resolved, err := ReadResolvedBootSeq(filename, overrides, profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]json.RawMessage
+	if err := decodeJSON(resolved, &doc); err != nil {
+		return nil, err
+	}
+
+	l := &linter{contents: map[string]bool{}, keys: map[string]bool{"ephemeral": true}}
+
+	var sections []string
+	for section := range doc {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		if !bootSeqSections[section] {
+			l.report("", "unknown section %q", section)
+		}
+	}
+
+	l.lintContents(doc["contents"])
+	l.lintKeys(doc["keys"])
+
+	var steps []map[string]json.RawMessage
+	if err := decodeJSON(orNull(doc["boot_sequence"]), &steps); err != nil {
+		return nil, fmt.Errorf("boot_sequence: %s", err)
+	}
+	for idx, step := range steps {
+		l.lintStep(fmt.Sprintf("step #%d", idx+1), "", step)
+	}
+
+	if len(l.issues) == 0 {
+		if _, err := ReadBootSeq(filename, overrides, profiles); err != nil {
+			l.report("", "%s", err)
+		}
+	}
+
+	return l.issues, nil
+}
+
+type linter struct {
+	issues   []*LintIssue
+	contents map[string]bool
+	keys     map[string]bool
+}
+
+func (l *linter) report(source string, format string, args ...interface{}) {
+	l.issues = append(l.issues, &LintIssue{
+		Source:  source,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintContents(raw json.RawMessage) {
+	var contents []map[string]interface{}
+	if err := decodeJSON(orNull(raw), &contents); err != nil {
+		l.report("", "contents: %s", err)
+		return
+	}
+
+	for idx, content := range contents {
+		source, _ := content["source"].(string)
+		name, _ := content["name"].(string)
+		where := fmt.Sprintf("content #%d", idx+1)
+		if name != "" {
+			where = fmt.Sprintf("content %q", name)
+		}
+
+		for _, field := range sortedKeys(content) {
+			switch field {
+			case "name", "url", "hash", "comment", "source":
+			default:
+				l.report(source, "%s: unknown field %q", where, field)
+			}
+		}
+
+		if name == "" {
+			l.report(source, "%s: name missing", where)
+		}
+		if url, _ := content["url"].(string); url == "" {
+			l.report(source, "%s: url missing", where)
+		}
+		if hash, _ := content["hash"].(string); hash == "" {
+			l.report(source, "%s: hash missing, the content could change under the boot sequence", where)
+		}
+
+		l.contents[name] = true
+	}
+}
+
+func (l *linter) lintKeys(raw json.RawMessage) {
+	var keys map[string]json.RawMessage
+	if err := decodeJSON(orNull(raw), &keys); err != nil {
+		l.report("", "keys: %s", err)
+		return
+	}
+
+	for _, name := range sortedRawKeys(keys) {
+		l.keys[name] = true
+
+		// `KeyDefinition.UnmarshalJSON` would let unknown fields
+		// through, maps are decoded as a plain struct.
+		type plainKeyDefinition KeyDefinition
+		def := &KeyDefinition{}
+		var err error
+		if bytes.HasPrefix(keys[name], []byte("{")) {
+			decoder := json.NewDecoder(bytes.NewReader(keys[name]))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode((*plainKeyDefinition)(def))
+		} else {
+			err = json.Unmarshal(keys[name], def)
+		}
+		if err != nil {
+			l.report("", "keys: %s: %s", name, strings.TrimPrefix(err.Error(), "json: "))
+			continue
+		}
+
+		if err := def.Check(); err != nil {
+			l.report("", "keys: %s: %s", name, err)
+			continue
+		}
+		if name == "ephemeral" && def.PrivateKey == "" && def.PrivateKeyFile == "" && def.PrivateKeyEnv == "" {
+			l.report("", "keys: ephemeral: the ephemeral key needs a private key")
+		}
+	}
+}
+
+// lintStep checks a step, `where` naming it in the issues. Steps of an
+// `msig.propose` take the `source` of the proposal.
+func (l *linter) lintStep(where, source string, step map[string]json.RawMessage) {
+	if rawSource, found := step["source"]; found {
+		_ = json.Unmarshal(rawSource, &source)
+	}
+
+	var op, id string
+	for _, field := range sortedRawKeys(step) {
+		var err error
+		switch strings.ToLower(field) {
+		case "op":
+			err = json.Unmarshal(step[field], &op)
+		case "id":
+			err = json.Unmarshal(step[field], &id)
+		case "label", "data", "source":
+		default:
+			l.report(source, "%s: unknown field %q", where, field)
+		}
+		if err != nil {
+			l.report(source, "%s: %s: %s", where, field, err)
+		}
+	}
+	if id != "" {
+		where = fmt.Sprintf("%s (%s)", where, id)
+	}
+
+	opType, found := operationsRegistry[op]
+	if !found {
+		l.report(source, "%s: unknown operation %q", where, op)
+		return
+	}
+	where = fmt.Sprintf("%s [%s]", where, op)
+
+	data := step["data"]
+	if len(data) == 0 || string(data) == "null" {
+		if !dataOptional[op] {
+			l.report(source, "%s: data missing", where)
+		}
+		return
+	}
+
+	obj := reflect.New(reflect.TypeOf(opType).Elem()).Interface()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		l.report(source, "%s: data: %s", where, strings.TrimPrefix(err.Error(), "json: "))
+		return
+	}
+
+	l.lintValue(where, source, reflect.ValueOf(obj), "")
+
+	switch typedOp := obj.(type) {
+	case *OpSetCode:
+		for _, ext := range []string{"wasm", "abi"} {
+			if !l.contents[typedOp.ContractNameRef+"."+ext] {
+				l.report(source, "%s: no %s.%s in contents", where, typedOp.ContractNameRef, ext)
+			}
+		}
+	case *OpMsigPropose:
+		var proposal struct {
+			Steps []map[string]json.RawMessage `json:"steps"`
+		}
+		_ = json.Unmarshal(data, &proposal)
+		for idx, proposed := range proposal.Steps {
+			l.lintStep(fmt.Sprintf("%s: steps #%d", where, idx+1), source, proposed)
+		}
+	}
+}
+
+// lintValue checks the account names and public keys in an operation,
+// `field` being the JSON name of `v`. Proposed steps are linted on
+// their own.
+func (l *linter) lintValue(where, source string, v reflect.Value, field string) {
+	if v.Type() == reflect.TypeOf(OperationType{}) {
+		return
+	}
+
+	if v.Type() == reflect.TypeOf(eos.AccountName("")) {
+		if name := v.String(); name != "" {
+			if err := ValidateAccountName(name); err != nil {
+				l.report(source, "%s: %s: %s", where, field, err)
+			}
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			l.lintValue(where, source, v.Elem(), field)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for idx := 0; idx < v.Len(); idx++ {
+			l.lintValue(where, source, v.Index(idx), field)
+		}
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			structField := v.Type().Field(idx)
+			if structField.PkgPath != "" {
+				continue
+			}
+			l.lintValue(where, source, v.Field(idx), jsonFieldName(structField))
+		}
+	case reflect.String:
+		if !publicKeyFields[strings.ToLower(field)] {
+			return
+		}
+
+		key := v.String()
+		if key == "ephemeral" || (key == "" && field == "block_signing_key") {
+			return
+		}
+		if strings.HasPrefix(key, keyRefPrefix) {
+			if !l.keys[strings.TrimPrefix(key, keyRefPrefix)] {
+				l.report(source, "%s: %s: no key %q in keys", where, field, strings.TrimPrefix(key, keyRefPrefix))
+			}
+			return
+		}
+		if _, err := ecc.NewPublicKey(key); err != nil {
+			l.report(source, "%s: %s: %q is neither `ephemeral`, a `key:` of keys nor a public key: %s", where, field, key, err)
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func sortedKeys(m map[string]interface{}) (out []string) {
+	for key := range m {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedRawKeys(m map[string]json.RawMessage) (out []string) {
+	for key := range m {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func orNull(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	return raw
+}
@@ -0,0 +1,81 @@
+package bios
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintBootSeq(t *testing.T) {
+	dir := writeBootSeqFiles(t, map[string]string{
+		"base.yaml": `keys:
+  producers: 5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3
+  ops: {private_key_env: OPS_KEY, permisions: [eosio.token@active]}
+contents:
+- name: eosio.system.abi
+  url: https://example.com/eosio.system.abi
+- name: eosio.system.wasm
+  url: https://example.com/eosio.system.wasm
+  hash: 2a4a2e0fe0ab8b1ca76c3dd1b1c0d95fb80b34bd4ab8ffe1b1f7e6a3ab4ec8a1
+boot_sequence:
+- op: system.newaccount
+  data:
+    creator: eosio
+    new_acount: eosio.msig
+- op: system.setcode
+  data:
+    account: Eosio
+    contract_name_ref: eosio.system
+- op: system.setcode
+  data:
+    account: eosio.token
+    contract_name_ref: eosio.token
+`,
+		"variant.yaml": `include: [base.yaml]
+boot_sequence:
+- op: system.setpriv
+- op: system.newaccount
+  labl: typo
+  data: {creator: eosio, new_account: eosio.msig, pubkey: EOS123}
+- op: system.nope
+- op: system.newaccount
+  data: {creator: eosio, new_account: producer1, pubkey: "key:producer"}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	issues, err := LintBootSeq(filepath.Join(dir, "variant.yaml"), nil, nil)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+
+	base := filepath.Join(dir, "base.yaml")
+	variant := filepath.Join(dir, "variant.yaml")
+	assert.Equal(t, []string{
+		base + `:5: content "eosio.system.abi": hash missing, the content could change under the boot sequence`,
+		`keys: ops: unknown field "permisions"`,
+		base + `:11: step #1 [system.newaccount]: data: unknown field "new_acount"`,
+		base + `:15: step #2 [system.setcode]: account: account name "Eosio" has invalid character 'E', only a-z, 1-5 and . are allowed`,
+		base + `:19: step #3 [system.setcode]: no eosio.token.wasm in contents`,
+		base + `:19: step #3 [system.setcode]: no eosio.token.abi in contents`,
+		variant + `:3: step #4 [system.setpriv]: data missing`,
+		variant + `:4: step #5: unknown field "labl"`,
+		variant + ":4: step #5 [system.newaccount]: pubkey: \"EOS123\" is neither `ephemeral`, a `key:` of keys nor a public key: invalid format",
+		variant + `:7: step #6: unknown operation "system.nope"`,
+		variant + `:8: step #7 [system.newaccount]: pubkey: no key "producer" in keys`,
+	}, messages)
+}
+
+func TestOperationTypeUnknownOp(t *testing.T) {
+	var op OperationType
+	err := json.Unmarshal([]byte(`{"op": "system.nope"}`), &op)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `operation type "system.nope" invalid, use one of: msig.approve, msig.exec, msig.propose, producer_api.preactivate, rex.buyrex,`)
+}
@@ -0,0 +1,230 @@
+package bios
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/eoscanada/eos-go"
+)
+
+// MaxPeerWeight is the highest `weight` a participant can give a
+// peer in `seed_network_peers`.
+const MaxPeerWeight = 100
+
+// Mesh is the weighted graph of the participants of a launch, built
+// from the `seed_network_peers` of their discovery files.
+type Mesh struct {
+	// Peers are in the order of the discovery files.
+	Peers []*MeshPeer
+	// Warnings are the problems found in the discovery files that
+	// didn't prevent building the mesh.
+	Warnings []string
+
+	peers map[eos.AccountName]*MeshPeer
+}
+
+// MeshPeer is a participant, and the links it declared to other
+// participants.
+type MeshPeer struct {
+	Account   eos.AccountName
+	Discovery *disco.Discovery
+	Links     []*disco.PeerLink
+
+	// TotalWeight is the sum of the weights other participants gave
+	// this one.
+	TotalWeight int
+	// Score is the transitive trust of the participant. Scores of all
+	// participants add up to 1.
+	Score float64
+}
+
+// Trust scores are computed like PageRank: a participant passes the
+// trust it receives to its peers, pro rata of the weights it gave
+// them, and `meshDamping` of it flows through links at each round.
+const (
+	meshDamping       = 0.85
+	meshMaxIterations = 1000
+	meshEpsilon       = 1e-12
+)
+
+// NewMesh builds the graph out of `discoveries`. Participants are
+// identified by their `seed_network_account_name`. Links to accounts
+// that didn't publish a discovery file are dropped, links to oneself
+// are kept in `Links` but carry no trust, and weights above
+// `MaxPeerWeight` are clamped, with a warning.
+func NewMesh(discoveries []*disco.Discovery) (*Mesh, error) {
+	m := &Mesh{peers: map[eos.AccountName]*MeshPeer{}}
+
+	for _, discovery := range discoveries {
+		account := discovery.SeedNetworkAccountName
+		if account == "" {
+			return nil, fmt.Errorf("discovery file without seed_network_account_name")
+		}
+		if _, found := m.peers[account]; found {
+			return nil, fmt.Errorf("%q has more than one discovery file", account)
+		}
+
+		peer := &MeshPeer{Account: account, Discovery: discovery}
+		m.Peers = append(m.Peers, peer)
+		m.peers[account] = peer
+	}
+
+	for _, peer := range m.Peers {
+		for _, link := range peer.Discovery.SeedNetworkPeers {
+			if link.Weight > MaxPeerWeight {
+				m.Warnings = append(m.Warnings, fmt.Sprintf("%q gives %q a weight of %d, clamped to %d", peer.Account, link.Account, link.Weight, MaxPeerWeight))
+				link = &disco.PeerLink{Account: link.Account, Comment: link.Comment, Weight: MaxPeerWeight}
+			}
+
+			target, found := m.peers[link.Account]
+			if !found {
+				continue
+			}
+
+			peer.Links = append(peer.Links, link)
+			if target != peer {
+				target.TotalWeight += int(link.Weight)
+			}
+		}
+	}
+
+	m.computeScores()
+
+	return m, nil
+}
+
+// Peer returns the participant `account`, or nil.
+func (m *Mesh) Peer(account eos.AccountName) *MeshPeer {
+	return m.peers[account]
+}
+
+func (m *Mesh) computeScores() {
+	count := len(m.Peers)
+	if count == 0 {
+		return
+	}
+
+	index := map[eos.AccountName]int{}
+	for idx, peer := range m.Peers {
+		index[peer.Account] = idx
+	}
+
+	scores := make([]float64, count)
+	for idx := range scores {
+		scores[idx] = 1 / float64(count)
+	}
+
+	for iteration := 0; iteration < meshMaxIterations; iteration++ {
+		next := make([]float64, count)
+		dangling := 0.0
+
+		for idx, peer := range m.Peers {
+			outWeight := 0
+			for _, link := range peer.Links {
+				if link.Account != peer.Account {
+					outWeight += int(link.Weight)
+				}
+			}
+
+			if outWeight == 0 {
+				dangling += scores[idx]
+				continue
+			}
+
+			for _, link := range peer.Links {
+				if link.Account == peer.Account {
+					continue
+				}
+				next[index[link.Account]] += meshDamping * scores[idx] * float64(link.Weight) / float64(outWeight)
+			}
+		}
+
+		// What doesn't flow through links is spread evenly, as is the
+		// trust of those who trust no one.
+		base := ((1-meshDamping)*(1-dangling) + dangling) / float64(count)
+
+		delta := 0.0
+		for idx := range next {
+			next[idx] += base
+			delta += math.Abs(next[idx] - scores[idx])
+		}
+
+		scores = next
+		if delta < meshEpsilon {
+			break
+		}
+	}
+
+	for idx, peer := range m.Peers {
+		peer.Score = scores[idx]
+	}
+}
+
+// Ranked returns the participants by decreasing trust score. Ties are
+// broken by `TotalWeight`, then by account name, so every participant
+// computes the same ranking.
+func (m *Mesh) Ranked() []*MeshPeer {
+	ranked := make([]*MeshPeer, len(m.Peers))
+	copy(ranked, m.Peers)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if math.Abs(a.Score-b.Score) > 1e-9 {
+			return a.Score > b.Score
+		}
+		if a.TotalWeight != b.TotalWeight {
+			return a.TotalWeight > b.TotalWeight
+		}
+		return a.Account < b.Account
+	})
+
+	return ranked
+}
+
+// BootNode is the most trusted participant, the one running `boot`.
+func (m *Mesh) BootNode() *MeshPeer {
+	ranked := m.Ranked()
+	if len(ranked) == 0 {
+		return nil
+	}
+	return ranked[0]
+}
+
+// AppointedBlockProducers are the `count` most trusted participants
+// after the boot node. Scheduling them is left to the boot sequence,
+// `orchestrate` has them join like everyone else.
+func (m *Mesh) AppointedBlockProducers(count int) []*MeshPeer {
+	ranked := m.Ranked()
+	if len(ranked) <= 1 {
+		return nil
+	}
+
+	ranked = ranked[1:]
+	if len(ranked) > count {
+		ranked = ranked[:count]
+	}
+	return ranked
+}
+
+// FlareNode is one entry of the `flare_N.json` files read by the d3
+// visualizations in `bios/test-data/mesh`.
+type FlareNode struct {
+	Name  string   `json:"name"`
+	Peers []string `json:"peers"`
+}
+
+// Flare exports the graph as a list of nodes and the peers they link
+// to, in the order they were declared.
+func (m *Mesh) Flare() []*FlareNode {
+	out := []*FlareNode{}
+	for _, peer := range m.Peers {
+		node := &FlareNode{Name: string(peer.Account), Peers: []string{}}
+		for _, link := range peer.Links {
+			node.Peers = append(node.Peers, string(link.Account))
+		}
+		out = append(out, node)
+	}
+	return out
+}
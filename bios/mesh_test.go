@@ -0,0 +1,103 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeshFlareFixtures(t *testing.T) {
+	for _, count := range []int{1, 2, 3, 4, 7, 12, 16, 21, 35, 56, 85, 121} {
+		t.Run(fmt.Sprintf("flare_%d", count), func(t *testing.T) {
+			cnt, err := ioutil.ReadFile(fmt.Sprintf("test-data/mesh/flare_%d.json", count))
+			require.NoError(t, err)
+
+			var flare []*FlareNode
+			require.NoError(t, json.Unmarshal(cnt, &flare))
+			require.Len(t, flare, count)
+
+			var discoveries []*disco.Discovery
+			for _, node := range flare {
+				discovery := &disco.Discovery{SeedNetworkAccountName: eos.AccountName(node.Name)}
+				for _, peer := range node.Peers {
+					discovery.SeedNetworkPeers = append(discovery.SeedNetworkPeers, &disco.PeerLink{Account: eos.AccountName(peer), Weight: 10})
+				}
+				discoveries = append(discoveries, discovery)
+			}
+
+			mesh, err := NewMesh(discoveries)
+			require.NoError(t, err)
+
+			assert.Equal(t, flare, mesh.Flare())
+
+			// Every fixture is symmetric, all nodes link to the same
+			// offsets, so trust must be spread evenly.
+			total := 0.0
+			for _, peer := range mesh.Peers {
+				assert.InDelta(t, 1/float64(count), peer.Score, 1e-6, "node %s", peer.Account)
+				total += peer.Score
+			}
+			assert.InDelta(t, 1, total, 1e-6)
+
+			assert.Equal(t, eos.AccountName("0"), mesh.BootNode().Account)
+		})
+	}
+}
+
+func TestMeshTrust(t *testing.T) {
+	discovery := func(account string, links ...interface{}) *disco.Discovery {
+		d := &disco.Discovery{SeedNetworkAccountName: eos.AccountName(account)}
+		for i := 0; i < len(links); i += 2 {
+			d.SeedNetworkPeers = append(d.SeedNetworkPeers, &disco.PeerLink{Account: eos.AccountName(links[i].(string)), Weight: uint8(links[i+1].(int))})
+		}
+		return d
+	}
+
+	mesh, err := NewMesh([]*disco.Discovery{
+		discovery("alice", "bob", 100, "carol", 10),
+		discovery("bob", "alice", 100, "unknown", 100),
+		discovery("carol", "bob", 50, "carol", 100),
+		discovery("dave", "carol", 100),
+		discovery("eve"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 150, mesh.Peer("bob").TotalWeight)
+	assert.Equal(t, 110, mesh.Peer("carol").TotalWeight)
+	assert.Equal(t, 0, mesh.Peer("eve").TotalWeight)
+	assert.Len(t, mesh.Peer("bob").Links, 1)
+
+	var ranking []eos.AccountName
+	for _, peer := range mesh.Ranked() {
+		ranking = append(ranking, peer.Account)
+	}
+	assert.Equal(t, []eos.AccountName{"bob", "alice", "carol", "dave", "eve"}, ranking)
+
+	// Dave's trust only reaches Alice through Carol and Bob, yet it
+	// does: Alice outranks Carol who received more direct weight.
+	assert.True(t, mesh.Peer("alice").Score > mesh.Peer("carol").Score)
+	assert.InDelta(t, mesh.Peer("dave").Score, mesh.Peer("eve").Score, 1e-9)
+
+	assert.Equal(t, eos.AccountName("bob"), mesh.BootNode().Account)
+	abps := mesh.AppointedBlockProducers(2)
+	require.Len(t, abps, 2)
+	assert.Equal(t, eos.AccountName("alice"), abps[0].Account)
+	assert.Equal(t, eos.AccountName("carol"), abps[1].Account)
+
+	// One participant giving too much weight doesn't prevent the
+	// launch, its link counts for `MaxPeerWeight`.
+	mesh, err = NewMesh([]*disco.Discovery{discovery("alice", "bob", 200), discovery("bob", "alice", 50), discovery("carol", "bob", 10)})
+	require.NoError(t, err)
+	assert.Equal(t, 110, mesh.Peer("bob").TotalWeight)
+	assert.Equal(t, uint8(MaxPeerWeight), mesh.Peer("alice").Links[0].Weight)
+	assert.Equal(t, []string{`"alice" gives "bob" a weight of 200, clamped to 100`}, mesh.Warnings)
+
+	_, err = NewMesh([]*disco.Discovery{discovery("alice"), discovery("alice")})
+	assert.EqualError(t, err, `"alice" has more than one discovery file`)
+}
@@ -0,0 +1,66 @@
+package bios
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// MsigApprovals is a row of the `approvals` table of `eosio.msig`,
+// scoped by proposer. Approving moves a permission from
+// `requested_approvals` to `provided_approvals`.
+type MsigApprovals struct {
+	ProposalName       eos.Name              `json:"proposal_name"`
+	RequestedApprovals []eos.PermissionLevel `json:"requested_approvals"`
+	ProvidedApprovals  []eos.PermissionLevel `json:"provided_approvals"`
+}
+
+// FetchMsigApprovals returns the approvals of `proposalName` by
+// `proposer`, or nil when there is no such proposal.
+func FetchMsigApprovals(api *eos.API, proposer eos.AccountName, proposalName eos.Name) (*MsigApprovals, error) {
+	key, err := eos.StringToName(string(proposalName))
+	if err != nil {
+		return nil, fmt.Errorf("proposal name %q: %s", proposalName, err)
+	}
+
+	resp, err := api.GetTableRows(eos.GetTableRowsRequest{
+		Code:       "eosio.msig",
+		Scope:      string(proposer),
+		Table:      "approvals",
+		LowerBound: strconv.FormatUint(key, 10),
+		Limit:      1,
+		JSON:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get approvals table: %s", err)
+	}
+
+	var rows []*MsigApprovals
+	if err := resp.JSONToStructs(&rows); err != nil {
+		return nil, fmt.Errorf("decoding approvals: %s", err)
+	}
+
+	if len(rows) == 0 || rows[0].ProposalName != proposalName {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// Missing are the permissions that still have to approve.
+func (a *MsigApprovals) Missing() []eos.PermissionLevel {
+	return a.RequestedApprovals
+}
+
+// WriteReport lists the approvals given and those still missing.
+func (a *MsigApprovals) WriteReport(w io.Writer) {
+	total := len(a.RequestedApprovals) + len(a.ProvidedApprovals)
+	fmt.Fprintf(w, "Proposal %s: %d of %d approvals\n", a.ProposalName, len(a.ProvidedApprovals), total)
+	for _, level := range a.ProvidedApprovals {
+		fmt.Fprintf(w, "  approved: %s@%s\n", level.Actor, level.Permission)
+	}
+	for _, level := range a.RequestedApprovals {
+		fmt.Fprintf(w, "  missing:  %s@%s\n", level.Actor, level.Permission)
+	}
+}
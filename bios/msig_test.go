@@ -0,0 +1,145 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/msig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsigOperations(t *testing.T) {
+	ephemeral, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	approverKey, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	keyFile, err := ioutil.TempFile("", "eos-bios-msig-keys")
+	require.NoError(t, err)
+	defer os.Remove(keyFile.Name())
+	_, err = keyFile.WriteString(approverKey.String() + "\n")
+	require.NoError(t, err)
+	require.NoError(t, keyFile.Close())
+
+	api := eos.New("http://localhost:1")
+	api.SetSigner(eos.NewKeyBag())
+	b := &BIOS{TargetNetAPI: api, EphemeralPublicKey: ephemeral.PublicKey()}
+
+	var bootSeq *BootSeq
+	require.NoError(t, yamlUnmarshal([]byte(`
+boot_sequence:
+- op: msig.propose
+  data:
+    proposer: produceraaaa
+    proposal_name: upgrade
+    requested:
+    - {actor: produceraaaa, permission: active}
+    - {actor: producerbbbb, permission: active}
+    expiration: "2019-01-01T00:00:00"
+    steps:
+    - op: system.setram
+      data:
+        max_ram_size: 68719476736
+    - op: system.setpriv
+      data:
+        account: eosio.msig
+- op: msig.approve
+  data:
+    proposer: produceraaaa
+    proposal_name: upgrade
+    approvers:
+    - {actor: produceraaaa, permission: active}
+    - {actor: producerbbbb, permission: active, key_file: "`+keyFile.Name()+`"}
+- op: msig.exec
+  data:
+    proposer: produceraaaa
+    proposal_name: upgrade
+`), &bootSeq))
+	require.Len(t, bootSeq.BootSequence, 3)
+
+	acts, err := bootSeq.BootSequence[0].Data.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 2)
+	propose := acts[0].ActionData.Data.(msig.Propose)
+	assert.Equal(t, eos.Name("upgrade"), propose.ProposalName)
+	assert.Len(t, propose.Requested, 2)
+	require.Len(t, propose.Transaction.Actions, 2)
+	assert.Equal(t, eos.ActionName("setram"), propose.Transaction.Actions[0].Name)
+	assert.Equal(t, "2019-01-01T00:00:00", propose.Transaction.Expiration.Format("2006-01-02T15:04:05"))
+
+	acts, err = bootSeq.BootSequence[1].Data.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 4)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "producerbbbb", Permission: "active"}}, acts[2].Authorization)
+
+	// Validation doesn't read the key files, `Boot` imports them.
+	available, err := api.Signer.AvailableKeys()
+	require.NoError(t, err)
+	assert.Empty(t, available)
+	require.NoError(t, bootSeq.BootSequence[1].Data.(KeyImporter).ImportKeys(b))
+
+	// Each approval is signed by the keys of its approver.
+	required := b.requiredKeys(&eos.Transaction{Actions: acts[0:1]}, ephemeral.PublicKey())
+	assert.Equal(t, []ecc.PublicKey{ephemeral.PublicKey()}, required)
+	required = b.requiredKeys(&eos.Transaction{Actions: acts[2:3]}, ephemeral.PublicKey())
+	assert.Equal(t, []ecc.PublicKey{approverKey.PublicKey()}, required)
+
+	available, err = api.Signer.AvailableKeys()
+	require.NoError(t, err)
+	assert.Equal(t, []ecc.PublicKey{approverKey.PublicKey()}, available)
+
+	acts, err = bootSeq.BootSequence[2].Data.Actions(b)
+	require.NoError(t, err)
+	exec := acts[0].ActionData.Data.(msig.Exec)
+	assert.Equal(t, eos.AccountName("produceraaaa"), exec.Executer)
+
+	_, err = (&OpMsigPropose{ProposalName: "upgrade", Requested: propose.Requested, Expiration: propose.Transaction.Expiration}).Actions(b)
+	assert.EqualError(t, err, `proposal "upgrade" has no actions`)
+
+	// A missing key file only fails the boot.
+	execOp := &OpMsigExec{Proposer: "produceraaaa", ProposalName: "upgrade", KeyFile: "/nonexistent.keys"}
+	_, err = execOp.Actions(b)
+	assert.NoError(t, err)
+	assert.Error(t, execOp.ImportKeys(b))
+}
+
+func TestFetchMsigApprovals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req eos.GetTableRowsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "eosio.msig", req.Code)
+		assert.Equal(t, "produceraaaa", req.Scope)
+		assert.Equal(t, "approvals", req.Table)
+
+		w.Write([]byte(`{"more": false, "rows": [
+			{"proposal_name": "upgrade",
+			 "requested_approvals": [{"actor": "producerbbbb", "permission": "active"}],
+			 "provided_approvals": [{"actor": "produceraaaa", "permission": "active"}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	approvals, err := FetchMsigApprovals(eos.New(server.URL), "produceraaaa", "upgrade")
+	require.NoError(t, err)
+	require.NotNil(t, approvals)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "producerbbbb", Permission: "active"}}, approvals.Missing())
+
+	buf := &bytes.Buffer{}
+	approvals.WriteReport(buf)
+	assert.Equal(t, `Proposal upgrade: 1 of 2 approvals
+  approved: produceraaaa@active
+  missing:  producerbbbb@active
+`, buf.String())
+
+	approvals, err = FetchMsigApprovals(eos.New(server.URL), "produceraaaa", "other")
+	require.NoError(t, err)
+	assert.Nil(t, approvals)
+}
@@ -2,13 +2,20 @@ package bios
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/eoscanada/eos-bios/bios/unregd"
 	eos "github.com/eoscanada/eos-go"
 	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/msig"
 	"github.com/eoscanada/eos-go/system"
 	"github.com/eoscanada/eos-go/token"
 )
@@ -17,21 +24,97 @@ type Operation interface {
 	Actions(b *BIOS) ([]*eos.Action, error)
 }
 
+// StreamingOperation is implemented by operations that can produce
+// too many actions to hold in memory at once, like the snapshot
+// injections. Actions are handed to `emit` as they are built, with
+// `nil` marking the end of a transaction, just like in the slice
+// returned by `Actions`.
+type StreamingOperation interface {
+	Operation
+	StreamActions(b *BIOS, emit func(*eos.Action) error) error
+}
+
+// APIOperation is implemented by operations that call the APIs of
+// the boot node instead of pushing actions, like
+// `producer_api.preactivate`. `Boot` runs them in order with the other
+// steps. They have no actions, so there is nothing to validate.
+type APIOperation interface {
+	Operation
+	Run(b *BIOS) error
+}
+
+// KeyImporter is implemented by operations signed with private keys
+// of their own, like `msig.approve`. `Boot` imports them before
+// pushing the step: `Actions` also runs for validation, which never
+// needs private keys.
+type KeyImporter interface {
+	ImportKeys(b *BIOS) error
+}
+
+// collectActions builds the `Actions` slice of a `StreamingOperation`.
+func collectActions(b *BIOS, op StreamingOperation) (out []*eos.Action, err error) {
+	err = op.StreamActions(b, func(act *eos.Action) error {
+		out = append(out, act)
+		return nil
+	})
+	return
+}
+
+func emitActions(emit func(*eos.Action) error, acts ...*eos.Action) error {
+	for _, act := range acts {
+		if err := emit(act); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var operationsRegistry = map[string]Operation{
 	"system.setcode":             &OpSetCode{},
 	"system.setram":              &OpSetRAM{},
+	"system.setramrate":          &OpSetRAMRate{},
+	"system.setparams":           &OpSetParams{},
+	"system.setalimits":          &OpSetALimits{},
+	"system.setglimits":          &OpSetGLimits{},
 	"system.newaccount":          &OpNewAccount{},
 	"system.setpriv":             &OpSetPriv{},
 	"token.create":               &OpCreateToken{},
 	"token.issue":                &OpIssueToken{},
+	"token.transfer":             &OpTransferToken{},
+	"token.distribute":           &OpDistributeToken{},
 	"system.setprods":            &OpSetProds{},
 	"snapshot.create_accounts":   &OpSnapshotCreateAccounts{},
 	"snapshot.load_unregistered": &OpInjectUnregdSnapshot{},
 	"system.resign_accounts":     &OpResignAccounts{},
 	"system.create_voters":       &OpCreateVoters{},
+	"system.regproducer":         &OpRegProducer{},
+	"system.voteproducer":        &OpVoteProducer{},
+	"system.updateauth":          &OpUpdateAuth{},
+	"system.linkauth":            &OpLinkAuth{},
+	"system.unlinkauth":          &OpUnlinkAuth{},
+	"msig.propose":               &OpMsigPropose{},
+	"msig.approve":               &OpMsigApprove{},
+	"msig.exec":                  &OpMsigExec{},
+	"producer_api.preactivate":   &OpPreactivateFeature{},
+	"system.activate":            &OpActivateFeature{},
+	"system.init":                &OpInitSystem{},
+	"system.bidname":             &OpBidName{},
+	"rex.buyrex":                 &OpBuyREX{},
+	"rex.setrex":                 &OpSetREX{},
+}
+
+// OperationNames returns the sorted names of the operations of the
+// boot sequence.
+func OperationNames() (out []string) {
+	for name := range operationsRegistry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
 }
 
 type OperationType struct {
+	ID    string
 	Op    string
 	Label string
 	Data  Operation
@@ -39,6 +122,7 @@ type OperationType struct {
 
 func (o *OperationType) UnmarshalJSON(data []byte) error {
 	opData := struct {
+		ID    string
 		Op    string
 		Label string
 		Data  json.RawMessage
@@ -49,7 +133,7 @@ func (o *OperationType) UnmarshalJSON(data []byte) error {
 
 	opType, found := operationsRegistry[opData.Op]
 	if !found {
-		return fmt.Errorf("operation type %q invalid, use one of: %q", opData.Op, operationsRegistry)
+		return fmt.Errorf("operation type %q invalid, use one of: %s", opData.Op, strings.Join(OperationNames(), ", "))
 	}
 
 	objType := reflect.TypeOf(opType).Elem()
@@ -70,6 +154,7 @@ func (o *OperationType) UnmarshalJSON(data []byte) error {
 	}
 
 	*o = OperationType{
+		ID:    opData.ID,
 		Op:    opData.Op,
 		Label: opData.Label,
 		Data:  opIface,
@@ -119,6 +204,197 @@ func (op *OpSetRAM) Actions(b *BIOS) (out []*eos.Action, err error) {
 
 //
 
+// OpSetRAMRate sets how many bytes of RAM are added to the supply
+// at each block.
+type OpSetRAMRate struct {
+	BytesPerBlock uint16 `json:"bytes_per_block"`
+}
+
+func (op *OpSetRAMRate) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return append(out, newSystemAction("setramrate", system.SetRAMRate{BytesPerBlock: op.BytesPerBlock})), nil
+}
+
+//
+
+// OpSetParams sets the blockchain parameters. Fields absent from
+// `params` keep their `DefaultBlockchainParameters` value.
+type OpSetParams struct {
+	Params json.RawMessage `json:"params"`
+}
+
+// SetParams is the `setparams` action of `eosio.system`.
+type SetParams struct {
+	Params system.BlockchainParameters `json:"params"`
+}
+
+// Parameters returns the full parameter set, validated.
+func (op *OpSetParams) Parameters() (*system.BlockchainParameters, error) {
+	params := DefaultBlockchainParameters()
+	if len(op.Params) != 0 {
+		if err := json.Unmarshal(op.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ValidateBlockchainParameters(&params); err != nil {
+		return nil, err
+	}
+
+	return &params, nil
+}
+
+func (op *OpSetParams) Actions(b *BIOS) (out []*eos.Action, err error) {
+	params, err := op.Parameters()
+	if err != nil {
+		return nil, fmt.Errorf("params: %s", err)
+	}
+
+	return append(out, newSystemAction("setparams", SetParams{Params: *params})), nil
+}
+
+// Percentages of the blockchain parameters are in hundredths of a
+// percent.
+const (
+	paramsPercent1   = 100
+	paramsPercent100 = 10000
+
+	// minNetUsageDeltaBetweenBaseAndMaxForTrx is the room nodeos
+	// requires between the base and max net usage of a transaction.
+	minNetUsageDeltaBetweenBaseAndMaxForTrx = 10 * 1024
+)
+
+// DefaultBlockchainParameters are the defaults of nodeos, those of a
+// chain that never called `setparams`.
+func DefaultBlockchainParameters() system.BlockchainParameters {
+	return system.BlockchainParameters{
+		MaxBlockNetUsage:               1024 * 1024,
+		TargetBlockNetUsagePct:         10 * paramsPercent1,
+		MaxTransactionNetUsage:         1024 * 1024 / 2,
+		BasePerTransactionNetUsage:     12,
+		NetUsageLeeway:                 500,
+		ContextFreeDiscountNetUsageNum: 20,
+		ContextFreeDiscountNetUsageDen: 100,
+		MaxBlockCPUUsage:               200000,
+		TargetBlockCPUUsagePct:         10 * paramsPercent1,
+		MaxTransactionCPUUsage:         3 * 200000 / 4,
+		MinTransactionCPUUsage:         100,
+		MaxTransactionLifetime:         60 * 60,
+		DeferredTrxExpirationWindow:    10 * 60,
+		MaxTransactionDelay:            45 * 24 * 3600,
+		MaxInlineActionSize:            4 * 1024,
+		MaxInlineActionDepth:           4,
+		MaxAuthorityDepth:              6,
+		MaxGeneratedTransactionCount:   16,
+	}
+}
+
+// ValidateBlockchainParameters applies the checks nodeos runs on
+// `setparams`, so a bad parameter set fails before the boot.
+func ValidateBlockchainParameters(p *system.BlockchainParameters) error {
+	switch {
+	case p.TargetBlockNetUsagePct > paramsPercent100:
+		return fmt.Errorf("target_block_net_usage_pct can't exceed 100%% (%d)", paramsPercent100)
+	case p.TargetBlockNetUsagePct < paramsPercent1/10:
+		return fmt.Errorf("target_block_net_usage_pct must be at least 0.1%% (%d)", paramsPercent1/10)
+	case p.TargetBlockCPUUsagePct > paramsPercent100:
+		return fmt.Errorf("target_block_cpu_usage_pct can't exceed 100%% (%d)", paramsPercent100)
+	case p.TargetBlockCPUUsagePct < paramsPercent1/10:
+		return fmt.Errorf("target_block_cpu_usage_pct must be at least 0.1%% (%d)", paramsPercent1/10)
+	case uint64(p.MaxTransactionNetUsage) >= uint64(p.MaxBlockNetUsage):
+		return fmt.Errorf("max_transaction_net_usage must be less than max_block_net_usage")
+	case p.MaxTransactionCPUUsage >= p.MaxBlockCPUUsage:
+		return fmt.Errorf("max_transaction_cpu_usage must be less than max_block_cpu_usage")
+	case p.BasePerTransactionNetUsage >= p.MaxTransactionNetUsage:
+		return fmt.Errorf("base_per_transaction_net_usage must be less than max_transaction_net_usage")
+	case p.MaxTransactionNetUsage-p.BasePerTransactionNetUsage < minNetUsageDeltaBetweenBaseAndMaxForTrx:
+		return fmt.Errorf("max_transaction_net_usage must be at least %d above base_per_transaction_net_usage", minNetUsageDeltaBetweenBaseAndMaxForTrx)
+	case p.ContextFreeDiscountNetUsageDen == 0:
+		return fmt.Errorf("context_free_discount_net_usage_den can't be 0")
+	case p.ContextFreeDiscountNetUsageNum > p.ContextFreeDiscountNetUsageDen:
+		return fmt.Errorf("context_free_discount_net_usage_num can't exceed context_free_discount_net_usage_den")
+	case p.MinTransactionCPUUsage > p.MaxTransactionCPUUsage:
+		return fmt.Errorf("min_transaction_cpu_usage can't exceed max_transaction_cpu_usage")
+	case p.MaxAuthorityDepth < 1:
+		return fmt.Errorf("max_authority_depth must be at least 1")
+	}
+	return nil
+}
+
+//
+
+// accountLimits are the resource limits of one account. `-1` means
+// unlimited, RAM in bytes and NET/CPU in weights.
+type accountLimits struct {
+	Account   eos.AccountName `json:"account"`
+	RAMBytes  int64           `json:"ram_bytes"`
+	NetWeight int64           `json:"net_weight"`
+	CPUWeight int64           `json:"cpu_weight"`
+}
+
+// OpSetALimits sets the resource limits of `accounts`, like unlimited
+// resources for system accounts or fixed ones for the members of a
+// consortium.
+type OpSetALimits struct {
+	Accounts []*accountLimits
+}
+
+func (op *OpSetALimits) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if len(op.Accounts) == 0 {
+		return nil, fmt.Errorf("no accounts to set limits for")
+	}
+
+	for _, limits := range op.Accounts {
+		if limits.RAMBytes < -1 || limits.NetWeight < -1 || limits.CPUWeight < -1 {
+			return nil, fmt.Errorf("limits of %q must be -1 (unlimited) or positive", limits.Account)
+		}
+
+		out = append(out, newSystemAction("setalimits", system.Setalimits{
+			Account:   limits.Account,
+			RAMBytes:  limits.RAMBytes,
+			NetWeight: limits.NetWeight,
+			CPUWeight: limits.CPUWeight,
+		}))
+	}
+
+	return
+}
+
+//
+
+// OpSetGLimits sets the global resource limits.
+type OpSetGLimits struct {
+	CPUUsecPerPeriod int64 `json:"cpu_usec_per_period"`
+}
+
+// SetGLimits is the `setglimits` action of the `eosio.bios` and
+// `eosio.system` contracts in `files/contracts`.
+type SetGLimits struct {
+	CPUUsecPerPeriod int64 `json:"cpu_usec_per_period"`
+}
+
+func (op *OpSetGLimits) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.CPUUsecPerPeriod <= 0 {
+		return nil, fmt.Errorf("cpu_usec_per_period must be positive")
+	}
+	return append(out, newSystemAction("setglimits", SetGLimits{CPUUsecPerPeriod: op.CPUUsecPerPeriod})), nil
+}
+
+// newSystemAction is an `eosio` action authorized by `eosio@active`.
+// The builders of eos-go get the names of `setalimits` and
+// `setramrate` wrong.
+func newSystemAction(name string, data interface{}) *eos.Action {
+	return &eos.Action{
+		Account: AN("eosio"),
+		Name:    eos.ActN(name),
+		Authorization: []eos.PermissionLevel{
+			{Actor: AN("eosio"), Permission: PN("active")},
+		},
+		ActionData: eos.NewActionData(data),
+	}
+}
+
+//
+
 type OpNewAccount struct {
 	Creator    eos.AccountName
 	NewAccount eos.AccountName `json:"new_account"`
@@ -126,52 +402,116 @@ type OpNewAccount struct {
 }
 
 func (op *OpNewAccount) Actions(b *BIOS) (out []*eos.Action, err error) {
-	pubKey := b.EphemeralPublicKey
-
-	if op.Pubkey != "ephemeral" {
-		pubKey, err = ecc.NewPublicKey(op.Pubkey)
-		if err != nil {
-			return nil, fmt.Errorf("reading pubkey: %s", err)
-		}
+	pubKey, err := b.PublicKey(op.Pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("reading pubkey: %s", err)
 	}
+	b.signWithKey(op.NewAccount, op.Pubkey)
 
 	return append(out, system.NewNewAccount(op.Creator, op.NewAccount, pubKey)), nil
 }
 
+// OpCreateVoters creates `count` accounts named after `name_prefix`
+// (`voter` by default, see `VoterName`), and funds them from
+// `creator`. Amounts are in the core symbol, and `0` skips a step.
+//
+// With a `key_seed`, each voter gets its own active key, derived
+// from the seed and its name, next to `pubkey` which keeps control
+// of the account. `export_csv` writes the accounts and their keys
+// for load-testing tools.
 type OpCreateVoters struct {
-	Creator eos.AccountName
-	Pubkey  string
-	Count   int
+	Creator    eos.AccountName
+	Pubkey     string
+	Count      int
+	NamePrefix string `json:"name_prefix"`
+
+	Transfer string `json:"transfer"`  // default 100000
+	RAMBytes *int   `json:"ram_bytes"` // default 8192, bought by eosio
+	Stake    string `json:"stake"`     // to CPU and to NET each, default 1
+
+	KeySeed   string `json:"key_seed"`
+	ExportCSV string `json:"export_csv"`
 }
 
 func (op *OpCreateVoters) Actions(b *BIOS) (out []*eos.Action, err error) {
-	pubKey := b.EphemeralPublicKey
+	pubKey, err := b.PublicKey(op.Pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("reading pubkey: %s", err)
+	}
 
-	if op.Pubkey != "ephemeral" {
-		pubKey, err = ecc.NewPublicKey(op.Pubkey)
-		if err != nil {
-			return nil, fmt.Errorf("reading pubkey: %s", err)
-		}
+	transferAmount, err := voterAmount(b, op.Transfer, "100000")
+	if err != nil {
+		return nil, fmt.Errorf("transfer: %s", err)
+	}
+	stakeAmount, err := voterAmount(b, op.Stake, "1")
+	if err != nil {
+		return nil, fmt.Errorf("stake: %s", err)
+	}
+	ramBytes := 8192
+	if op.RAMBytes != nil {
+		ramBytes = *op.RAMBytes
 	}
 
-	for i := 0; i < op.Count; i++ {
-		voterName := eos.AccountName(voterName(i))
-		fmt.Println("Creating voter: ", voterName)
-		out = append(out, system.NewNewAccount(op.Creator, voterName, pubKey))
-		out = append(out, token.NewTransfer(op.Creator, voterName, eos.NewEOSAsset(1000000000), ""))
-		out = append(out, system.NewBuyRAMBytes(AN("eosio"), voterName, 8192)) // 8kb gift !
-		out = append(out, system.NewDelegateBW(AN("eosio"), voterName, eos.NewEOSAsset(10000), eos.NewEOSAsset(10000), true))
+	voters, err := GenerateVoters(op.NamePrefix, op.Count, op.KeySeed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, voter := range voters {
+		b.Log.Debugf("Creating voter %s\n", voter.Account)
+		b.signWithKey(voter.Account, op.Pubkey)
 
+		if voter.PrivateKey == nil {
+			out = append(out, system.NewNewAccount(op.Creator, voter.Account, pubKey))
+		} else {
+			out = append(out, system.NewCustomNewAccount(op.Creator, voter.Account, keyAuthority(pubKey), keyAuthority(pubKey, voter.PublicKey)))
+		}
+		if transferAmount.Amount != 0 {
+			out = append(out, token.NewTransfer(op.Creator, voter.Account, transferAmount, ""))
+		}
+		if ramBytes != 0 {
+			out = append(out, system.NewBuyRAMBytes(AN("eosio"), voter.Account, uint32(ramBytes)))
+		}
+		if stakeAmount.Amount != 0 {
+			out = append(out, system.NewDelegateBW(AN("eosio"), voter.Account, stakeAmount, stakeAmount, true))
+		}
+		out = append(out, nil)
 	}
 
 	return
 }
 
-const charset = "abcdefghijklmnopqrstuvwxyz"
+// Export writes the voters and their keys to `export_csv`. It is run
+// once by `Boot`, after the voters are created, as `Actions` also
+// runs for validation.
+func (op *OpCreateVoters) Export(b *BIOS) error {
+	if op.ExportCSV == "" {
+		return nil
+	}
+
+	pubKey, err := b.PublicKey(op.Pubkey)
+	if err != nil {
+		return fmt.Errorf("reading pubkey: %s", err)
+	}
+
+	voters, err := GenerateVoters(op.NamePrefix, op.Count, op.KeySeed)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteVotersCSV(op.ExportCSV, voters, pubKey); err != nil {
+		return fmt.Errorf("export_csv: %s", err)
+	}
+
+	b.Log.Printf("Exported %d voters, from %s to %s, to %q\n", len(voters), voters[0].Account, voters[len(voters)-1].Account, op.ExportCSV)
+	return nil
+}
 
-func voterName(index int) string {
-	padding := string(bytes.Repeat([]byte{charset[index]}, 7))
-	return "voter" + padding
+func voterAmount(b *BIOS, amount, defaultAmount string) (eos.Asset, error) {
+	if amount == "" {
+		amount = defaultAmount
+	}
+	return b.NewCoreAsset(amount)
 }
 
 type OpSetPriv struct {
@@ -184,174 +524,629 @@ func (op *OpSetPriv) Actions(b *BIOS) (out []*eos.Action, err error) {
 
 //
 
+// OpCreateToken creates the `amount` symbol on a token `contract`,
+// `eosio.token` by default, with `account` as issuer.
 type OpCreateToken struct {
-	Account eos.AccountName `json:"account"`
-	Amount  eos.Asset       `json:"amount"`
+	Contract eos.AccountName `json:"contract"`
+	Account  eos.AccountName `json:"account"`
+	Amount   eos.Asset       `json:"amount"`
 }
 
 func (op *OpCreateToken) Actions(b *BIOS) (out []*eos.Action, err error) {
+	contract := tokenContract(op.Contract)
+
 	act := token.NewCreate(op.Account, op.Amount)
+	act.Account = contract
+	act.Authorization = []eos.PermissionLevel{{Actor: contract, Permission: PN("active")}}
 	return append(out, act), nil
 }
 
 //
 
+// OpIssueToken issues `amount` to `account`, authorized by the
+// `issuer` given to `token.create`, `eosio` by default.
 type OpIssueToken struct {
-	Account eos.AccountName
-	Amount  eos.Asset
-	Memo    string
+	Contract eos.AccountName `json:"contract"`
+	Issuer   eos.AccountName `json:"issuer"`
+	Account  eos.AccountName
+	Amount   eos.Asset
+	Memo     string
+}
+
+// TokenContract is the contract issuing the token.
+func (op *OpIssueToken) TokenContract() eos.AccountName {
+	return tokenContract(op.Contract)
 }
 
 func (op *OpIssueToken) Actions(b *BIOS) (out []*eos.Action, err error) {
+	issuer := op.Issuer
+	if issuer == "" {
+		issuer = AN("eosio")
+	}
+
 	act := token.NewIssue(op.Account, op.Amount, op.Memo)
+	act.Account = op.TokenContract()
+	act.Authorization = []eos.PermissionLevel{{Actor: issuer, Permission: PN("active")}}
 	return append(out, act), nil
 }
 
+func tokenContract(contract eos.AccountName) eos.AccountName {
+	if contract == "" {
+		return AN("eosio.token")
+	}
+	return contract
+}
+
+func newTokenTransfer(contract, from, to eos.AccountName, quantity eos.Asset, memo string) *eos.Action {
+	act := token.NewTransfer(from, to, quantity, memo)
+	act.Account = tokenContract(contract)
+	return act
+}
+
 //
 
-type OpSnapshotCreateAccounts struct {
-	BuyRAMBytes             uint64 `json:"buy_ram_bytes"`
-	TestnetTruncateSnapshot int    `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+// OpTransferToken transfers `amount` of a token of `contract`,
+// `eosio.token` by default.
+type OpTransferToken struct {
+	Contract eos.AccountName `json:"contract"`
+	From     eos.AccountName `json:"from"`
+	To       eos.AccountName `json:"to"`
+	Amount   eos.Asset       `json:"amount"`
+	Memo     string          `json:"memo"`
 }
 
-func (op *OpSnapshotCreateAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
-	snapshotFile, err := b.GetContentsCacheRef("snapshot.csv")
-	if err != nil {
-		return nil, err
+func (op *OpTransferToken) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.Amount.Amount <= 0 {
+		return nil, fmt.Errorf("transfer amount must be positive")
 	}
+	return append(out, newTokenTransfer(op.Contract, op.From, op.To, op.Amount, op.Memo)), nil
+}
 
-	rawSnapshot, err := b.ReadFromCache(snapshotFile)
-	if err != nil {
-		return nil, fmt.Errorf("reading snapshot file: %s", err)
+//
+
+// OpDistributeToken airdrops a token of `contract` from `from`, to
+// the `account,amount` lines of the `contents_ref` CSV file. A first
+// line starting with `account` is a header, and skipped. The amounts,
+// in `symbol`, must not add up to more than what `from` holds after
+// the steps before it.
+type OpDistributeToken struct {
+	Contract                eos.AccountName `json:"contract"`
+	From                    eos.AccountName `json:"from"`
+	Symbol                  *Symbol         `json:"symbol"`
+	ContentsRef             string          `json:"contents_ref"`
+	Memo                    string          `json:"memo"`
+	TransfersPerTransaction int             `json:"transfers_per_transaction"`
+}
+
+func (op *OpDistributeToken) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return collectActions(b, op)
+}
+
+func (op *OpDistributeToken) StreamActions(b *BIOS, emit func(*eos.Action) error) error {
+	if op.Symbol == nil {
+		return fmt.Errorf("missing symbol, like `4,XYZ`")
 	}
+	symbol := eos.Symbol(*op.Symbol)
 
-	snapshotData, err := NewSnapshot(rawSnapshot)
+	perTransaction := op.TransfersPerTransaction
+	if perTransaction <= 0 {
+		perTransaction = 10
+	}
+
+	available, err := op.availableBefore(b, symbol)
 	if err != nil {
-		return nil, fmt.Errorf("loading snapshot csv: %s", err)
+		return err
 	}
 
-	if len(snapshotData) == 0 {
-		return nil, fmt.Errorf("snapshot is empty or not loaded")
+	total := eos.Asset{Symbol: symbol}
+	count := 0
+	err = op.readDistribution(b, symbol, func(account eos.AccountName, amount eos.Asset) error {
+		total = total.Add(amount)
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%s is empty", op.ContentsRef)
+	}
+	if total.Amount > available.Amount {
+		return fmt.Errorf("%s distributes %s, more than the %s %s holds before it", op.ContentsRef, total, available, op.From)
 	}
 
-	wellKnownPubkey, _ := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	b.Log.Debugf("- distributing %s to %d accounts, out of %s held by %s\n", total, count, available, op.From)
 
-	for idx, hodler := range snapshotData {
-		if trunc := op.TestnetTruncateSnapshot; trunc != 0 {
-			if idx == trunc {
-				b.Log.Debugf("- DEBUG: truncated snapshot to %d rows\n", trunc)
-				break
-			}
+	idx := 0
+	return op.readDistribution(b, symbol, func(account eos.AccountName, amount eos.Asset) error {
+		if err := emit(newTokenTransfer(op.Contract, op.From, account, amount, op.Memo)); err != nil {
+			return err
 		}
-
-		destAccount := AN(hodler.AccountName)
-		destPubKey := hodler.EOSPublicKey
-		if b.HackVotingAccounts {
-			destPubKey = wellKnownPubkey
+		idx++
+		if idx%perTransaction == 0 || idx == count {
+			return emit(nil)
 		}
+		return nil
+	})
+}
 
-		out = append(out, system.NewNewAccount(AN("eosio"), destAccount, destPubKey))
+// availableBefore is what `from` holds of `symbol` on the same
+// contract after the steps before this one: what `token.issue` and
+// `token.transfer` steps gave it, less what it transferred and
+// distributed. Proposed steps only run once approved, so they don't
+// count, and a step proposed in an `msig.propose` is checked against
+// what `from` holds before the proposal.
+func (op *OpDistributeToken) availableBefore(b *BIOS, symbol eos.Symbol) (eos.Asset, error) {
+	available := eos.Asset{Symbol: symbol}
+	if b.BootSequence == nil {
+		return available, fmt.Errorf("no boot sequence to check the balance of %s against", op.From)
+	}
 
-		cpuStake, netStake, rest := splitSnapshotStakes(hodler.Balance)
+	contract := tokenContract(op.Contract)
+	sameToken := func(otherContract eos.AccountName, otherSymbol eos.Symbol) bool {
+		return tokenContract(otherContract) == contract && otherSymbol == symbol
+	}
 
-		// special case `transfer` for `b1` ?
-		out = append(out, system.NewDelegateBW(AN("eosio"), destAccount, cpuStake, netStake, true))
-		out = append(out, system.NewBuyRAMBytes(AN("eosio"), destAccount, uint32(op.BuyRAMBytes)))
-		out = append(out, nil) // end transaction
+	for _, step := range b.BootSequence.BootSequence {
+		if containsOperation(step.Data, op) {
+			break
+		}
 
-		memo := "Welcome " + hodler.EthereumAddress[len(hodler.EthereumAddress)-6:]
-		out = append(out, token.NewTransfer(AN("eosio"), destAccount, rest, memo), nil)
+		switch prev := step.Data.(type) {
+		case *OpIssueToken:
+			if sameToken(prev.Contract, prev.Amount.Symbol) && prev.Account == op.From {
+				available = available.Add(prev.Amount)
+			}
+		case *OpTransferToken:
+			if !sameToken(prev.Contract, prev.Amount.Symbol) {
+				continue
+			}
+			if prev.To == op.From {
+				available = available.Add(prev.Amount)
+			}
+			if prev.From == op.From {
+				available = available.Sub(prev.Amount)
+			}
+		case *OpDistributeToken:
+			if prev.From != op.From || prev.Symbol == nil || !sameToken(prev.Contract, eos.Symbol(*prev.Symbol)) {
+				continue
+			}
+			err := prev.readDistribution(b, symbol, func(account eos.AccountName, amount eos.Asset) error {
+				available = available.Sub(amount)
+				return nil
+			})
+			if err != nil {
+				return available, err
+			}
+		}
 	}
 
-	return
+	return available, nil
 }
 
-func splitSnapshotStakes(balance eos.Asset) (cpu, net, xfer eos.Asset) {
-	if balance.Amount < 5000 {
-		return
+// containsOperation is true when `op` is `data`, or one of the steps
+// it proposes.
+func containsOperation(data Operation, op Operation) bool {
+	if data == op {
+		return true
 	}
+	if propose, ok := data.(*OpMsigPropose); ok {
+		for _, step := range propose.Steps {
+			if containsOperation(step.Data, op) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	// everyone has minimum 0.25 EOS staked
-	// some 10 EOS unstaked
-	// the rest split between the two
+func (op *OpDistributeToken) readDistribution(b *BIOS, symbol eos.Symbol, f func(account eos.AccountName, amount eos.Asset) error) error {
+	ref, err := b.GetContentsCacheRef(op.ContentsRef)
+	if err != nil {
+		return err
+	}
 
-	cpu = eos.NewEOSAsset(2500)
-	net = eos.NewEOSAsset(2500)
+	fl, err := b.ReaderFromCache(ref)
+	if err != nil {
+		return fmt.Errorf("reading %s: %s", op.ContentsRef, err)
+	}
+	defer fl.Close()
 
-	remainder := eos.NewEOSAsset(int64(balance.Amount - cpu.Amount - net.Amount))
+	reader := csv.NewReader(fl)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
 
-	if remainder.Amount <= 100000 /* 10.0 EOS */ {
-		return cpu, net, remainder
-	}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", op.ContentsRef, err)
+		}
 
-	remainder.Amount -= 100000 // keep them floating, unstaked
+		if line == 1 && record[0] == "account" {
+			continue
+		}
 
-	firstHalf := remainder.Amount / 2
-	cpu.Amount += firstHalf
-	net.Amount += remainder.Amount - firstHalf
+		if err := ValidateAccountName(record[0]); err != nil {
+			return fmt.Errorf("%s: line %d: %s", op.ContentsRef, line, err)
+		}
+		amount, err := NewAssetFromString(record[1], symbol)
+		if err != nil {
+			return fmt.Errorf("%s: line %d: %s", op.ContentsRef, line, err)
+		}
+		if amount.Amount <= 0 {
+			return fmt.Errorf("%s: line %d: amount must be positive", op.ContentsRef, line)
+		}
 
-	return cpu, net, eos.NewEOSAsset(100000)
+		if err := f(AN(record[0]), amount); err != nil {
+			return err
+		}
+	}
 }
 
 //
 
-type OpInjectUnregdSnapshot struct {
-	TestnetTruncateSnapshot int `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+type OpSnapshotCreateAccounts struct {
+	BuyRAMBytes             uint64          `json:"buy_ram_bytes"`
+	Format                  *SnapshotFormat `json:"format"`
+	Distribution            json.RawMessage `json:"distribution"`
+	TestnetTruncateSnapshot int             `json:"TESTNET_TRUNCATE_SNAPSHOT"`
 }
 
-func (op *OpInjectUnregdSnapshot) Actions(b *BIOS) (out []*eos.Action, err error) {
-	snapshotFile, err := b.GetContentsCacheRef("snapshot_unregistered.csv")
-	if err != nil {
+// Policy returns the distribution policy of the snapshot balances in
+// `symbol`, with the top-level `buy_ram_bytes` applied. Fields absent
+// from `distribution` keep their `DefaultStakePolicy` value.
+func (op *OpSnapshotCreateAccounts) Policy(symbol eos.Symbol) (*StakePolicy, error) {
+	policy := DefaultStakePolicy(symbol)
+	if len(op.Distribution) != 0 {
+		if err := json.Unmarshal(op.Distribution, &policy); err != nil {
+			return nil, err
+		}
+	}
+
+	if policy.BuyRAMBytes == 0 && policy.BuyRAM.Amount == 0 {
+		policy.BuyRAMBytes = uint32(op.BuyRAMBytes)
+	}
+
+	if err := policy.Validate(symbol); err != nil {
 		return nil, err
 	}
 
-	rawSnapshot, err := b.ReadFromCache(snapshotFile)
+	return &policy, nil
+}
+
+func (op *OpSnapshotCreateAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return collectActions(b, op)
+}
+
+func (op *OpSnapshotCreateAccounts) StreamActions(b *BIOS, emit func(*eos.Action) error) error {
+	policy, err := op.Policy(b.CoreSymbol())
+	if err != nil {
+		return fmt.Errorf("distribution: %s", err)
+	}
+
+	snapshotFile, err := b.GetContentsCacheRef("snapshot.csv")
 	if err != nil {
-		return nil, fmt.Errorf("reading snapshot file: %s", err)
+		return err
 	}
 
-	snapshotData, err := NewUnregdSnapshot(rawSnapshot)
+	rawSnapshot, err := b.ReaderFromCache(snapshotFile)
 	if err != nil {
-		return nil, fmt.Errorf("loading snapshot csv: %s", err)
+		return fmt.Errorf("reading snapshot file: %s", err)
 	}
+	defer rawSnapshot.Close()
 
-	if len(snapshotData) == 0 {
-		return nil, fmt.Errorf("snapshot is empty or not loaded")
+	snapshotData, err := NewSnapshotReader(rawSnapshot, op.Format, b.CoreSymbol())
+	if err != nil {
+		return fmt.Errorf("snapshot format: %s", err)
 	}
 
-	for idx, hodler := range snapshotData {
+	wellKnownPubkey, _ := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+
+	idx := 0
+	for ; ; idx++ {
 		if trunc := op.TestnetTruncateSnapshot; trunc != 0 {
 			if idx == trunc {
-				b.Log.Debugf("- DEBUG: truncated unreg'd snapshot to %d rows\n", trunc)
+				b.Log.Debugf("- DEBUG: truncated snapshot to %d rows\n", trunc)
 				break
 			}
 		}
 
-		//system.NewDelegatedNewAccount(AN("eosio"), AN(hodler.AccountName), AN("eosio.unregd"))
+		hodler, err := snapshotData.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("loading snapshot csv: %s", err)
+		}
 
-		out = append(out,
-			unregd.NewAdd(hodler.EthereumAddress, hodler.Balance),
-			token.NewTransfer(AN("eosio"), AN("eosio.unregd"), hodler.Balance, "Future claim"),
-			nil,
-		)
-	}
+		if hodler.AccountName == "" {
+			return fmt.Errorf("loading snapshot csv: line %d: no account name, map the %q column", snapshotData.Line(), SnapshotAccountName)
+		}
 
-	return
-}
+		destAccount := AN(hodler.AccountName)
+		destPubKey := hodler.EOSPublicKey
+		if b.HackVotingAccounts {
+			destPubKey = wellKnownPubkey
+		}
 
-//
+		cpuStake, netStake, rest := splitSnapshotStakes(policy, hodler.Balance)
+		if hodler.Balance.Amount < policy.MinimumBalance().Amount && policy.SmallBalances == SmallBalancesNone {
+			b.Log.Debugf("- line %d: balance %s of %s under %s, not distributed\n", snapshotData.Line(), hodler.Balance, destAccount, policy.MinimumBalance())
+		}
 
-type producerKeyString struct {
-	ProducerName          eos.AccountName `json:"producer_name"`
-	BlockSigningKeyString string          `json:"block_signing_key"`
-}
+		acts := []*eos.Action{system.NewNewAccount(AN("eosio"), destAccount, destPubKey)}
+		if cpuStake.Amount != 0 || netStake.Amount != 0 {
+			// special case `transfer` for `b1` ?
+			acts = append(acts, system.NewDelegateBW(AN("eosio"), destAccount, cpuStake, netStake, policy.TransferStakes))
+		}
+		if policy.BuyRAMBytes != 0 {
+			acts = append(acts, system.NewBuyRAMBytes(AN("eosio"), destAccount, policy.BuyRAMBytes))
+		}
+		if policy.BuyRAM.Amount != 0 {
+			acts = append(acts, newBuyRAM(AN("eosio"), destAccount, policy.BuyRAM))
+		}
+		acts = append(acts, nil) // end transaction
 
-type OpSetProds struct {
-	Prods []producerKeyString
-}
+		if rest.Amount != 0 {
+			acts = append(acts, token.NewTransfer(AN("eosio"), destAccount, rest, policy.FormatMemo(hodler)), nil)
+		}
 
-func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
-	// We he can at least process the last few blocks, that wrap up
+		if err = emitActions(emit, acts...); err != nil {
+			return err
+		}
+	}
+
+	if idx == 0 {
+		return fmt.Errorf("snapshot is empty or not loaded")
+	}
+
+	return nil
+}
+
+// Ways to handle balances under the minimum CPU and NET stakes.
+const (
+	SmallBalancesNone   = "none"
+	SmallBalancesLiquid = "liquid"
+	SmallBalancesStake  = "stake"
+)
+
+// StakePolicy decides how `snapshot.create_accounts` splits each
+// balance between CPU stake, NET stake and liquid tokens.
+type StakePolicy struct {
+	MinimumCPUStake eos.Asset `json:"minimum_cpu_stake"`
+	MinimumNETStake eos.Asset `json:"minimum_net_stake"`
+
+	// LiquidAmount is kept unstaked, once the minimum stakes are
+	// covered. Anything above is staked.
+	LiquidAmount eos.Asset `json:"liquid_amount"`
+
+	// CPURatio is the share of the staked amount above the minimums
+	// that goes to CPU, the rest goes to NET.
+	CPURatio float64 `json:"cpu_ratio"`
+
+	// TransferStakes gives the stakes to the account, instead of
+	// keeping them delegated by `eosio`.
+	TransferStakes bool `json:"transfer_stakes"`
+
+	// SmallBalances handles balances under the minimum stakes: `none`
+	// leaves them with `eosio`, `liquid` transfers them all and
+	// `stake` splits them all between CPU and NET.
+	SmallBalances string `json:"small_balances"`
+
+	// Memo of the liquid tokens transfer. `{ethereum_suffix}`,
+	// `{ethereum_address}` and `{account_name}` are replaced by the
+	// values of each snapshot line.
+	Memo string `json:"memo"`
+
+	// BuyRAMBytes and BuyRAM buy RAM for the account, paid by
+	// `eosio`, in bytes or in tokens.
+	BuyRAMBytes uint32    `json:"buy_ram_bytes"`
+	BuyRAM      eos.Asset `json:"buy_ram"`
+}
+
+// DefaultStakePolicy is the distribution used at the EOS mainnet
+// launch, with amounts in `symbol`.
+func DefaultStakePolicy(symbol eos.Symbol) StakePolicy {
+	return StakePolicy{
+		MinimumCPUStake: rescaleAsset(eos.NewEOSAsset(2500), symbol),   // 0.25
+		MinimumNETStake: rescaleAsset(eos.NewEOSAsset(2500), symbol),   // 0.25
+		LiquidAmount:    rescaleAsset(eos.NewEOSAsset(100000), symbol), // 10.0
+		CPURatio:        0.5,
+		TransferStakes:  true,
+		SmallBalances:   SmallBalancesNone,
+		Memo:            "Welcome {ethereum_suffix}",
+		BuyRAM:          eos.Asset{Symbol: symbol},
+	}
+}
+
+// Validate checks the policy is consistent, with amounts in `symbol`.
+func (p *StakePolicy) Validate(symbol eos.Symbol) error {
+	if p.CPURatio < 0 || p.CPURatio > 1 {
+		return fmt.Errorf("cpu_ratio %f should be between 0 and 1", p.CPURatio)
+	}
+
+	switch p.SmallBalances {
+	case SmallBalancesNone, SmallBalancesLiquid, SmallBalancesStake:
+	default:
+		return fmt.Errorf("small_balances %q invalid, use one of %q", p.SmallBalances, []string{SmallBalancesNone, SmallBalancesLiquid, SmallBalancesStake})
+	}
+
+	for name, amount := range map[string]eos.Asset{
+		"minimum_cpu_stake": p.MinimumCPUStake,
+		"minimum_net_stake": p.MinimumNETStake,
+		"liquid_amount":     p.LiquidAmount,
+		"buy_ram":           p.BuyRAM,
+	} {
+		if amount.Amount < 0 {
+			return fmt.Errorf("%s %s can't be negative", name, amount)
+		}
+		if amount.Symbol != symbol {
+			return fmt.Errorf("%s %s not in core symbol %s", name, amount, symbol)
+		}
+	}
+
+	return nil
+}
+
+// MinimumBalance is the smallest balance that covers the minimum
+// stakes.
+func (p *StakePolicy) MinimumBalance() eos.Asset {
+	return p.MinimumCPUStake.Add(p.MinimumNETStake)
+}
+
+// MinimumStakeOnlyBalance is the largest balance that gets no more
+// than the minimum stakes.
+func (p *StakePolicy) MinimumStakeOnlyBalance() eos.Asset {
+	return p.MinimumBalance().Add(p.LiquidAmount)
+}
+
+func (p *StakePolicy) FormatMemo(hodler SnapshotLine) string {
+	suffix := hodler.EthereumAddress
+	if len(suffix) > 6 {
+		suffix = suffix[len(suffix)-6:]
+	}
+
+	return strings.NewReplacer(
+		"{ethereum_suffix}", suffix,
+		"{ethereum_address}", hodler.EthereumAddress,
+		"{account_name}", hodler.AccountName,
+	).Replace(p.Memo)
+}
+
+func newBuyRAM(payer, receiver eos.AccountName, quantity eos.Asset) *eos.Action {
+	return &eos.Action{
+		Account: AN("eosio"),
+		Name:    eos.ActN("buyram"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: payer, Permission: PN("active")},
+		},
+		ActionData: eos.NewActionData(system.BuyRAM{
+			Payer:    payer,
+			Receiver: receiver,
+			Quantity: quantity,
+		}),
+	}
+}
+
+func splitSnapshotStakes(policy *StakePolicy, balance eos.Asset) (cpu, net, xfer eos.Asset) {
+	zero := eos.Asset{Symbol: balance.Symbol}
+	cpu, net, xfer = zero, zero, zero
+
+	if balance.Amount < policy.MinimumBalance().Amount {
+		switch policy.SmallBalances {
+		case SmallBalancesLiquid:
+			xfer = balance
+		case SmallBalancesStake:
+			cpu.Amount = eos.Int64(float64(balance.Amount) * policy.CPURatio)
+			net.Amount = balance.Amount - cpu.Amount
+		}
+		return
+	}
+
+	// everyone has the minimum stakes (0.25 EOS each by default)
+	// some liquid tokens unstaked (10 EOS by default)
+	// the rest split between the two
+
+	cpu.Amount = policy.MinimumCPUStake.Amount
+	net.Amount = policy.MinimumNETStake.Amount
+
+	remainder := balance.Amount - cpu.Amount - net.Amount
+
+	if remainder <= policy.LiquidAmount.Amount {
+		xfer.Amount = remainder
+		return
+	}
+
+	remainder -= policy.LiquidAmount.Amount // keep them floating, unstaked
+
+	cpuShare := eos.Int64(float64(remainder) * policy.CPURatio)
+	cpu.Amount += cpuShare
+	net.Amount += remainder - cpuShare
+	xfer.Amount = policy.LiquidAmount.Amount
+
+	return
+}
+
+//
+
+type OpInjectUnregdSnapshot struct {
+	Format                  *SnapshotFormat `json:"format"`
+	TestnetTruncateSnapshot int             `json:"TESTNET_TRUNCATE_SNAPSHOT"`
+}
+
+func (op *OpInjectUnregdSnapshot) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return collectActions(b, op)
+}
+
+func (op *OpInjectUnregdSnapshot) StreamActions(b *BIOS, emit func(*eos.Action) error) error {
+	snapshotFile, err := b.GetContentsCacheRef("snapshot_unregistered.csv")
+	if err != nil {
+		return err
+	}
+
+	rawSnapshot, err := b.ReaderFromCache(snapshotFile)
+	if err != nil {
+		return fmt.Errorf("reading snapshot file: %s", err)
+	}
+	defer rawSnapshot.Close()
+
+	snapshotData, err := NewUnregdSnapshotReader(rawSnapshot, op.Format, b.CoreSymbol())
+	if err != nil {
+		return fmt.Errorf("snapshot format: %s", err)
+	}
+
+	idx := 0
+	for ; ; idx++ {
+		if trunc := op.TestnetTruncateSnapshot; trunc != 0 {
+			if idx == trunc {
+				b.Log.Debugf("- DEBUG: truncated unreg'd snapshot to %d rows\n", trunc)
+				break
+			}
+		}
+
+		hodler, err := snapshotData.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("loading snapshot csv: %s", err)
+		}
+
+		//system.NewDelegatedNewAccount(AN("eosio"), AN(hodler.AccountName), AN("eosio.unregd"))
+
+		err = emitActions(emit,
+			unregd.NewAdd(hodler.EthereumAddress, hodler.Balance),
+			token.NewTransfer(AN("eosio"), AN("eosio.unregd"), hodler.Balance, "Future claim"),
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if idx == 0 {
+		return fmt.Errorf("snapshot is empty or not loaded")
+	}
+
+	return nil
+}
+
+//
+
+type producerKeyString struct {
+	ProducerName          eos.AccountName `json:"producer_name"`
+	BlockSigningKeyString string          `json:"block_signing_key"`
+}
+
+type OpSetProds struct {
+	Prods []producerKeyString
+}
+
+func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
+	// We he can at least process the last few blocks, that wrap up
 	// and resigns the system accounts.
 
 	var prodKeys []system.ProducerKey
@@ -363,9 +1158,9 @@ func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
 		if key.BlockSigningKeyString == "" || key.BlockSigningKeyString == "ephemeral" {
 			prodKey.BlockSigningKey = b.EphemeralPublicKey
 		} else {
-			k, err := ecc.NewPublicKeyFromData([]byte(key.BlockSigningKeyString))
+			k, err := b.PublicKey(key.BlockSigningKeyString)
 			if err != nil {
-				panic(err)
+				return nil, fmt.Errorf("reading block_signing_key of %q: %s", key.ProducerName, err)
 			}
 			prodKey.BlockSigningKey = k
 		}
@@ -386,6 +1181,145 @@ func (op *OpSetProds) Actions(b *BIOS) (out []*eos.Action, err error) {
 
 //
 
+type producerRegistration struct {
+	Account         eos.AccountName `json:"account"`
+	BlockSigningKey string          `json:"block_signing_key"`
+	URL             string          `json:"url"`
+	Location        uint16          `json:"location"`
+}
+
+// OpRegProducer registers block producer candidates, so they can be
+// voted for. An empty or `ephemeral` `block_signing_key` uses the
+// ephemeral key of the boot.
+type OpRegProducer struct {
+	Producers []*producerRegistration
+}
+
+func (op *OpRegProducer) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if len(op.Producers) == 0 {
+		return nil, fmt.Errorf("no producers to register")
+	}
+
+	for _, prod := range op.Producers {
+		pubKey := b.EphemeralPublicKey
+		if prod.BlockSigningKey != "" {
+			pubKey, err = b.PublicKey(prod.BlockSigningKey)
+			if err != nil {
+				return nil, fmt.Errorf("reading block_signing_key of %q: %s", prod.Account, err)
+			}
+		}
+
+		out = append(out, system.NewRegProducer(prod.Account, pubKey, prod.URL, prod.Location), nil)
+	}
+
+	return
+}
+
+//
+
+// MaxVotedProducers is the most producers a single vote can go to in
+// `eosio.system`.
+const MaxVotedProducers = 30
+
+// OpVoteProducer votes for producers, from the `voters` listed and
+// the first `voter_count` accounts created by `system.create_voters`
+// with `voter_prefix`. Voter N votes for
+// `producer_sets[N % len(producer_sets)]`, so the votes can be spread
+// to elect more than 30 producers. With `stake`
+// set, each voter first stakes that much of its own tokens to CPU and
+// to NET, to weigh in the 15% needed to activate the chain.
+type OpVoteProducer struct {
+	Voters       []eos.AccountName
+	VoterCount   int                 `json:"voter_count"`
+	VoterPrefix  string              `json:"voter_prefix"`
+	Proxy        eos.AccountName     `json:"proxy"`
+	ProducerSets [][]eos.AccountName `json:"producer_sets"`
+	Stake        string              `json:"stake"`
+}
+
+func (op *OpVoteProducer) Actions(b *BIOS) (out []*eos.Action, err error) {
+	voters := append([]eos.AccountName{}, op.Voters...)
+	for i := 0; i < op.VoterCount; i++ {
+		name, err := VoterName(op.VoterPrefix, i)
+		if err != nil {
+			return nil, err
+		}
+		voters = append(voters, AN(name))
+	}
+	if len(voters) == 0 {
+		return nil, fmt.Errorf("no voters, list some in `voters` or set `voter_count`")
+	}
+
+	if op.Proxy == "" && len(op.ProducerSets) == 0 {
+		return nil, fmt.Errorf("no `producer_sets` to vote for, and no `proxy`")
+	}
+
+	var sets [][]eos.AccountName
+	for idx, set := range op.ProducerSets {
+		sorted, err := sortProducers(set)
+		if err != nil {
+			return nil, fmt.Errorf("producer_sets[%d]: %s", idx, err)
+		}
+		sets = append(sets, sorted)
+	}
+
+	var stake eos.Asset
+	if op.Stake != "" {
+		stake, err = b.NewCoreAsset(op.Stake)
+		if err != nil {
+			return nil, fmt.Errorf("stake: %s", err)
+		}
+	}
+
+	for idx, voter := range voters {
+		if stake.Amount != 0 {
+			out = append(out, system.NewDelegateBW(voter, voter, stake, stake, false))
+		}
+
+		var producers []eos.AccountName
+		if op.Proxy == "" {
+			producers = sets[idx%len(sets)]
+		}
+		out = append(out, system.NewVoteProducer(voter, op.Proxy, producers...), nil)
+	}
+
+	return
+}
+
+// sortProducers orders `producers` by name value, as `voteproducer`
+// requires, and rejects duplicates.
+func sortProducers(producers []eos.AccountName) ([]eos.AccountName, error) {
+	if len(producers) == 0 {
+		return nil, fmt.Errorf("empty producer set")
+	}
+	if len(producers) > MaxVotedProducers {
+		return nil, fmt.Errorf("%d producers, a vote goes to %d at most", len(producers), MaxVotedProducers)
+	}
+
+	values := map[eos.AccountName]uint64{}
+	for _, prod := range producers {
+		if _, found := values[prod]; found {
+			return nil, fmt.Errorf("%q listed twice", prod)
+		}
+		value, err := eos.StringToName(string(prod))
+		if err != nil {
+			return nil, fmt.Errorf("invalid producer name %q: %s", prod, err)
+		}
+		values[prod] = value
+	}
+
+	sorted := append([]eos.AccountName{}, producers...)
+	sort.Slice(sorted, func(i, j int) bool { return values[sorted[i]] < values[sorted[j]] })
+
+	return sorted, nil
+}
+
+//
+
+// OpResignAccounts points the `owner` and `active` permissions of
+// system `accounts` to `eosio@active`, and those of `eosio` to
+// `eosio.prods@active`, a special account granted by 2/3 + 1 of the
+// current producers schedule. It is a preset of `system.updateauth`.
 type OpResignAccounts struct {
 	Accounts            []eos.AccountName
 	TestnetKeepAccounts bool `json:"TESTNET_KEEP_ACCOUNTS"`
@@ -398,8 +1332,8 @@ func (op *OpResignAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
 	}
 
 	systemAccount := AN("eosio")
-	prodsAccount := AN("eosio.prods") // this is a special system account that is granted by 2/3 + 1 of the current BP schedule.
 
+	var updates []*OpUpdateAuth
 	eosioPresent := false
 	for _, acct := range op.Accounts {
 		if acct == systemAccount {
@@ -407,64 +1341,584 @@ func (op *OpResignAccounts) Actions(b *BIOS) (out []*eos.Action, err error) {
 			continue
 		}
 
-		out = append(out,
-			system.NewUpdateAuth(acct, PN("active"), PN("owner"), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      AN("eosio"),
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("active")),
-			system.NewUpdateAuth(acct, PN("owner"), PN(""), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      AN("eosio"),
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("owner")),
-		)
+		updates = append(updates, resignUpdates(acct, systemAccount)...)
 	}
 
 	if eosioPresent {
-		out = append(out,
-			system.NewUpdateAuth(systemAccount, PN("active"), PN("owner"), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      prodsAccount,
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("active")),
-			system.NewUpdateAuth(systemAccount, PN("owner"), PN(""), eos.Authority{
-				Threshold: 1,
-				Accounts: []eos.PermissionLevelWeight{
-					eos.PermissionLevelWeight{
-						Permission: eos.PermissionLevel{
-							Actor:      prodsAccount,
-							Permission: PN("active"),
-						},
-						Weight: 1,
-					},
-				},
-			}, PN("owner")),
-		)
+		updates = append(updates, resignUpdates(systemAccount, AN("eosio.prods"))...)
+	}
+
+	for _, update := range updates {
+		acts, err := update.Actions(b)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, acts...)
 	}
 
 	out = append(out, nil)
 
 	return
 }
+
+// resignUpdates hands the `active` and `owner` permissions of
+// `account` over to `to@active`.
+func resignUpdates(account, to eos.AccountName) []*OpUpdateAuth {
+	auth := AuthorityDefinition{
+		Threshold: 1,
+		Accounts: []eos.PermissionLevelWeight{
+			{Permission: eos.PermissionLevel{Actor: to, Permission: PN("active")}, Weight: 1},
+		},
+	}
+
+	return []*OpUpdateAuth{
+		{Account: account, Permission: PN("active"), Parent: PN("owner"), Auth: auth},
+		{Account: account, Permission: PN("owner"), Auth: auth},
+	}
+}
+
+//
+
+// AuthorityDefinition is an `eos.Authority` as written in a boot
+// sequence, where keys can be `ephemeral`.
+type AuthorityDefinition struct {
+	Threshold uint32                      `json:"threshold"`
+	Keys      []*KeyWeightDefinition      `json:"keys"`
+	Accounts  []eos.PermissionLevelWeight `json:"accounts"`
+	Waits     []eos.WaitWeight            `json:"waits"`
+}
+
+type KeyWeightDefinition struct {
+	Key    string `json:"key"`
+	Weight uint16 `json:"weight"`
+}
+
+// Authority resolves the keys, sorts keys, accounts and waits the way
+// the chain requires, and checks the threshold can be met.
+func (d *AuthorityDefinition) Authority(b *BIOS) (out eos.Authority, err error) {
+	if d.Threshold == 0 {
+		return out, fmt.Errorf("threshold must be positive")
+	}
+
+	out.Threshold = d.Threshold
+
+	totalWeight := 0
+	var keys []ecc.PublicKey
+	keyWeights := map[string]uint16{}
+	for _, kw := range d.Keys {
+		key, err := b.PublicKey(kw.Key)
+		if err != nil {
+			return out, fmt.Errorf("reading key %q: %s", kw.Key, err)
+		}
+		if _, found := keyWeights[key.String()]; found {
+			return out, fmt.Errorf("key %s listed twice", key)
+		}
+		keyWeights[key.String()] = kw.Weight
+		keys = append(keys, key)
+		totalWeight += int(kw.Weight)
+	}
+	for _, kw := range keyAuthority(keys...).Keys {
+		kw.Weight = keyWeights[kw.PublicKey.String()]
+		out.Keys = append(out.Keys, kw)
+	}
+
+	accountNames := map[eos.AccountName]uint64{}
+	seen := map[eos.PermissionLevel]bool{}
+	for _, plw := range d.Accounts {
+		if seen[plw.Permission] {
+			return out, fmt.Errorf("account %s@%s listed twice", plw.Permission.Actor, plw.Permission.Permission)
+		}
+		seen[plw.Permission] = true
+
+		value, err := eos.StringToName(string(plw.Permission.Actor))
+		if err != nil {
+			return out, fmt.Errorf("invalid account %q: %s", plw.Permission.Actor, err)
+		}
+		accountNames[plw.Permission.Actor] = value
+		out.Accounts = append(out.Accounts, plw)
+		totalWeight += int(plw.Weight)
+	}
+	sort.SliceStable(out.Accounts, func(i, j int) bool {
+		a, b := out.Accounts[i].Permission, out.Accounts[j].Permission
+		if a.Actor != b.Actor {
+			return accountNames[a.Actor] < accountNames[b.Actor]
+		}
+		aPerm, _ := eos.StringToName(string(a.Permission))
+		bPerm, _ := eos.StringToName(string(b.Permission))
+		return aPerm < bPerm
+	})
+
+	out.Waits = append(out.Waits, d.Waits...)
+	for _, wait := range d.Waits {
+		totalWeight += int(wait.Weight)
+	}
+	sort.SliceStable(out.Waits, func(i, j int) bool { return out.Waits[i].WaitSec < out.Waits[j].WaitSec })
+
+	if totalWeight < int(d.Threshold) {
+		return out, fmt.Errorf("threshold %d unreachable, weights only add up to %d", d.Threshold, totalWeight)
+	}
+
+	return out, nil
+}
+
+// OpUpdateAuth sets the `permission` of `account` to `auth`, under
+// `parent`. It is authorized by `using_permission`, which defaults to
+// `owner` when updating `owner` and to `active` otherwise.
+type OpUpdateAuth struct {
+	Account         eos.AccountName
+	Permission      eos.PermissionName
+	Parent          eos.PermissionName
+	Auth            AuthorityDefinition
+	UsingPermission eos.PermissionName `json:"using_permission"`
+}
+
+func (op *OpUpdateAuth) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.Permission == "" {
+		return nil, fmt.Errorf("missing permission to update on %q", op.Account)
+	}
+	if op.Permission == PN("owner") && op.Parent != "" {
+		return nil, fmt.Errorf("the owner permission of %q can't have a parent", op.Account)
+	}
+	if op.Permission != PN("owner") && op.Parent == "" {
+		return nil, fmt.Errorf("missing parent of %s@%s", op.Account, op.Permission)
+	}
+
+	auth, err := op.Auth.Authority(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %s", op.Account, op.Permission, err)
+	}
+
+	usingPermission := op.UsingPermission
+	if usingPermission == "" {
+		usingPermission = PN("active")
+		if op.Permission == PN("owner") {
+			usingPermission = PN("owner")
+		}
+	}
+
+	return append(out, system.NewUpdateAuth(op.Account, op.Permission, op.Parent, auth, usingPermission)), nil
+}
+
+//
+
+// OpLinkAuth requires `requirement` of `account` to authorize the
+// `type` action of `code`. An empty `type` links every action of
+// `code`.
+type OpLinkAuth struct {
+	Account     eos.AccountName
+	Code        eos.AccountName
+	Type        eos.ActionName
+	Requirement eos.PermissionName
+}
+
+func (op *OpLinkAuth) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.Requirement == "" {
+		return nil, fmt.Errorf("missing requirement to link %s to %s::%s", op.Account, op.Code, op.Type)
+	}
+	return append(out, system.NewLinkAuth(op.Account, op.Code, op.Type, op.Requirement)), nil
+}
+
+//
+
+type OpUnlinkAuth struct {
+	Account eos.AccountName
+	Code    eos.AccountName
+	Type    eos.ActionName
+}
+
+func (op *OpUnlinkAuth) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return append(out, system.NewUnlinkAuth(op.Account, op.Code, op.Type)), nil
+}
+
+//
+
+// OpMsigPropose proposes, through `eosio.msig`, a transaction made of
+// the actions of nested `steps`, to be approved by the `requested`
+// permissions. `expiration` is fixed in the boot sequence, so every
+// participant validates the same proposal.
+//
+// `key_file` holds the private keys of the `proposer`, when the
+// ephemeral key doesn't control it. Like for `msig.approve` and
+// `msig.exec`, keep such files out of published boot sequences.
+type OpMsigPropose struct {
+	Proposer     eos.AccountName
+	ProposalName eos.Name              `json:"proposal_name"`
+	Requested    []eos.PermissionLevel `json:"requested"`
+	Expiration   eos.JSONTime          `json:"expiration"`
+	DelaySec     uint32                `json:"delay_sec"`
+	Steps        []*OperationType      `json:"steps"`
+	KeyFile      string                `json:"key_file"`
+}
+
+func (op *OpMsigPropose) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.ProposalName == "" {
+		return nil, fmt.Errorf("missing proposal_name")
+	}
+	if len(op.Requested) == 0 {
+		return nil, fmt.Errorf("no `requested` approvals for proposal %q", op.ProposalName)
+	}
+	if op.Expiration.IsZero() {
+		return nil, fmt.Errorf("missing expiration for proposal %q", op.ProposalName)
+	}
+
+	tx := &eos.Transaction{
+		TransactionHeader: eos.TransactionHeader{
+			Expiration: op.Expiration,
+			DelaySec:   eos.Varuint32(op.DelaySec),
+		},
+	}
+	for _, step := range op.Steps {
+		err := b.streamActions(step.Data, func(act *eos.Action) error {
+			if act != nil {
+				tx.Actions = append(tx.Actions, act)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("proposal %q step %q: %s", op.ProposalName, step.Op, err)
+		}
+	}
+	if len(tx.Actions) == 0 {
+		return nil, fmt.Errorf("proposal %q has no actions", op.ProposalName)
+	}
+
+	return append(out, msig.NewPropose(op.Proposer, op.ProposalName, op.Requested, tx), nil), nil
+}
+
+func (op *OpMsigPropose) ImportKeys(b *BIOS) error {
+	return importMsigKeys(b, op.Proposer, op.KeyFile)
+}
+
+type msigApprover struct {
+	eos.PermissionLevel
+	KeyFile string `json:"key_file"`
+}
+
+// OpMsigApprove approves a proposal from each of the `approvers`, in
+// separate transactions, signed with their `key_file` or else with
+// the ephemeral key.
+type OpMsigApprove struct {
+	Proposer     eos.AccountName
+	ProposalName eos.Name        `json:"proposal_name"`
+	Approvers    []*msigApprover `json:"approvers"`
+}
+
+func (op *OpMsigApprove) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if len(op.Approvers) == 0 {
+		return nil, fmt.Errorf("no approvers for proposal %q", op.ProposalName)
+	}
+
+	for _, approver := range op.Approvers {
+		out = append(out, msig.NewApprove(op.Proposer, op.ProposalName, approver.PermissionLevel), nil)
+	}
+
+	return
+}
+
+func (op *OpMsigApprove) ImportKeys(b *BIOS) error {
+	for _, approver := range op.Approvers {
+		if approver.KeyFile != "" {
+			if err := b.ImportPermissionKeys(approver.PermissionLevel, approver.KeyFile); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// OpMsigExec executes an approved proposal, on behalf of `executer`.
+type OpMsigExec struct {
+	Proposer     eos.AccountName
+	ProposalName eos.Name        `json:"proposal_name"`
+	Executer     eos.AccountName `json:"executer"`
+	KeyFile      string          `json:"key_file"`
+}
+
+func (op *OpMsigExec) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return append(out, msig.NewExec(op.Proposer, op.ProposalName, op.executer()), nil), nil
+}
+
+func (op *OpMsigExec) ImportKeys(b *BIOS) error {
+	return importMsigKeys(b, op.executer(), op.KeyFile)
+}
+
+// executer defaults to the `proposer`.
+func (op *OpMsigExec) executer() eos.AccountName {
+	if op.Executer == "" {
+		return op.Proposer
+	}
+	return op.Executer
+}
+
+func importMsigKeys(b *BIOS, account eos.AccountName, keyFile string) error {
+	if keyFile == "" {
+		return nil
+	}
+	return b.ImportPermissionKeys(eos.PermissionLevel{Actor: account, Permission: PN("active")}, keyFile)
+}
+
+//
+
+// OpPreactivateFeature schedules protocol features through the
+// `schedule_protocol_feature_activations` endpoint of the producer
+// API, at `producer_api_url` or else the target network API. It is
+// how `PREACTIVATE_FEATURE` gets activated on nodeos 1.8 and up.
+type OpPreactivateFeature struct {
+	Features       []string `json:"features"`
+	ProducerAPIURL string   `json:"producer_api_url"`
+}
+
+func (op *OpPreactivateFeature) Actions(b *BIOS) (out []*eos.Action, err error) {
+	return nil, nil
+}
+
+// features defaults to `PREACTIVATE_FEATURE`.
+func (op *OpPreactivateFeature) features() []string {
+	if len(op.Features) == 0 {
+		return []string{PreactivateFeature}
+	}
+	return op.Features
+}
+
+func (op *OpPreactivateFeature) Run(b *BIOS) error {
+	req := struct {
+		ProtocolFeaturesToActivate []eos.Checksum256 `json:"protocol_features_to_activate"`
+	}{}
+	for _, feature := range op.features() {
+		digest, err := FeatureDigest(feature)
+		if err != nil {
+			return err
+		}
+		req.ProtocolFeaturesToActivate = append(req.ProtocolFeaturesToActivate, digest)
+	}
+
+	baseURL := op.ProducerAPIURL
+	if baseURL == "" {
+		baseURL = b.TargetNetAPI.BaseURL
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.TargetNetAPI.HttpClient.Post(strings.TrimRight(baseURL, "/")+"/v1/producer/schedule_protocol_feature_activations", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("scheduling protocol features: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		cnt, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("scheduling protocol features: status %d: %s", resp.StatusCode, string(cnt))
+	}
+
+	return nil
+}
+
+// OpActivateFeature activates protocol features through the
+// `activate` action of the `eosio.bios` or `eosio.system` contracts
+// of nodeos 1.8 and up, by codename or digest. With `all`, it
+// activates every builtin feature but `PREACTIVATE_FEATURE`.
+type OpActivateFeature struct {
+	Features []string `json:"features"`
+	All      bool     `json:"all"`
+}
+
+// Activate is the `activate` action of `eosio`.
+type Activate struct {
+	FeatureDigest eos.Checksum256 `json:"feature_digest"`
+}
+
+func (op *OpActivateFeature) digests() (out []eos.Checksum256, err error) {
+	features := op.Features
+	if op.All {
+		features = activationOrder
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("no features to activate, list some or set `all`")
+	}
+
+	for _, feature := range features {
+		digest, err := FeatureDigest(feature)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, digest)
+	}
+	return
+}
+
+func (op *OpActivateFeature) Actions(b *BIOS) (out []*eos.Action, err error) {
+	digests, err := op.digests()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, digest := range digests {
+		out = append(out, newSystemAction("activate", Activate{FeatureDigest: digest}))
+	}
+	return
+}
+
+//
+
+// OpInitSystem calls `init` on `eosio.system` 1.5 and up, which sets
+// the core symbol and opens the RAM market and REX. `core_symbol`
+// defaults to the one of the boot sequence.
+type OpInitSystem struct {
+	Version    uint32 `json:"version"`
+	CoreSymbol string `json:"core_symbol"`
+}
+
+// InitSystem is the `init` action of `eosio.system`. `Core` is the
+// packed symbol, precision in the lowest byte.
+type InitSystem struct {
+	Version eos.Varuint32 `json:"version"`
+	Core    uint64        `json:"core"`
+}
+
+func (op *OpInitSystem) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if op.Version != 0 {
+		return nil, fmt.Errorf("unsupported version %d, eosio.system only knows 0", op.Version)
+	}
+
+	symbol := b.CoreSymbol()
+	if op.CoreSymbol != "" {
+		symbol, err = eos.StringToSymbol(op.CoreSymbol)
+		if err != nil {
+			return nil, fmt.Errorf("core_symbol: %s", err)
+		}
+	}
+
+	core, err := symbol.ToUint64()
+	if err != nil {
+		return nil, fmt.Errorf("core_symbol: %s", err)
+	}
+
+	return append(out, newSystemAction("init", InitSystem{Version: eos.Varuint32(op.Version), Core: core})), nil
+}
+
+//
+
+type nameBid struct {
+	Bidder  eos.AccountName `json:"bidder"`
+	NewName eos.AccountName `json:"newname"`
+	Bid     string          `json:"bid"`
+}
+
+// OpBidName places bids on premium names, those shorter than 12
+// characters and without a dot. Bidders need the `bid` amount in
+// liquid tokens.
+type OpBidName struct {
+	Bids []*nameBid
+}
+
+func (op *OpBidName) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if len(op.Bids) == 0 {
+		return nil, fmt.Errorf("no bids")
+	}
+
+	for _, bid := range op.Bids {
+		if err := ValidateAccountName(string(bid.NewName)); err != nil {
+			return nil, err
+		}
+		if len(bid.NewName) >= 12 || strings.Contains(string(bid.NewName), ".") {
+			return nil, fmt.Errorf("%q isn't a premium name, only names under 12 characters without a dot are auctioned", bid.NewName)
+		}
+
+		amount, err := b.NewCoreAsset(bid.Bid)
+		if err != nil {
+			return nil, fmt.Errorf("bid of %s on %q: %s", bid.Bidder, bid.NewName, err)
+		}
+		if amount.Amount <= 0 {
+			return nil, fmt.Errorf("bid of %s on %q must be positive", bid.Bidder, bid.NewName)
+		}
+
+		out = append(out, system.NewBidname(bid.Bidder, bid.NewName, amount), nil)
+	}
+
+	return
+}
+
+//
+
+type rexDeposit struct {
+	Account eos.AccountName `json:"account"`
+	Amount  string          `json:"amount"`
+}
+
+// OpBuyREX seeds REX: each account deposits `amount` of its liquid
+// tokens to its REX fund, and lends them all through `buyrex`.
+type OpBuyREX struct {
+	Deposits []*rexDeposit
+}
+
+// Deposit is the `deposit` action of `eosio.system`.
+type Deposit struct {
+	Owner  eos.AccountName `json:"owner"`
+	Amount eos.Asset       `json:"amount"`
+}
+
+// BuyREX is the `buyrex` action of `eosio.system`.
+type BuyREX struct {
+	From   eos.AccountName `json:"from"`
+	Amount eos.Asset       `json:"amount"`
+}
+
+func (op *OpBuyREX) Actions(b *BIOS) (out []*eos.Action, err error) {
+	if len(op.Deposits) == 0 {
+		return nil, fmt.Errorf("no deposits")
+	}
+
+	for _, deposit := range op.Deposits {
+		amount, err := b.NewCoreAsset(deposit.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("deposit of %s: %s", deposit.Account, err)
+		}
+		if amount.Amount <= 0 {
+			return nil, fmt.Errorf("deposit of %s must be positive", deposit.Account)
+		}
+
+		out = append(out,
+			newAccountAction(deposit.Account, "deposit", Deposit{Owner: deposit.Account, Amount: amount}),
+			newAccountAction(deposit.Account, "buyrex", BuyREX{From: deposit.Account, Amount: amount}),
+			nil,
+		)
+	}
+
+	return
+}
+
+//
+
+// OpSetREX sets the total of the REX pool, adjusting the rent prices
+// of a test chain without real lending.
+type OpSetREX struct {
+	Balance string `json:"balance"`
+}
+
+// SetREX is the `setrex` action of `eosio.system`.
+type SetREX struct {
+	Balance eos.Asset `json:"balance"`
+}
+
+func (op *OpSetREX) Actions(b *BIOS) (out []*eos.Action, err error) {
+	balance, err := b.NewCoreAsset(op.Balance)
+	if err != nil {
+		return nil, fmt.Errorf("balance: %s", err)
+	}
+	if balance.Amount <= 0 {
+		return nil, fmt.Errorf("balance must be positive")
+	}
+
+	return append(out, newSystemAction("setrex", SetREX{Balance: balance})), nil
+}
+
+// newAccountAction is an `eosio` action authorized by
+// `account@active`.
+func newAccountAction(account eos.AccountName, name string, data interface{}) *eos.Action {
+	act := newSystemAction(name, data)
+	act.Authorization = []eos.PermissionLevel{{Actor: account, Permission: PN("active")}}
+	return act
+}
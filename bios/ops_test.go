@@ -1,62 +1,545 @@
 package bios
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestStakePolicyMemo(t *testing.T) {
+	hodler := SnapshotLine{EthereumAddress: "0xf23221e40732b34d84db1d30da95367a160da090", AccountName: "gi2dmnzxgege"}
+
+	policy := DefaultStakePolicy(eos.EOSSymbol)
+	assert.Equal(t, "Welcome 0da090", policy.FormatMemo(hodler))
+
+	policy.Memo = "Hello {account_name} from {ethereum_address}"
+	assert.Equal(t, "Hello gi2dmnzxgege from 0xf23221e40732b34d84db1d30da95367a160da090", policy.FormatMemo(hodler))
+}
+
 func TestSnapshotDelegationAmounts(t *testing.T) {
 	tests := []struct {
 		balance  eos.Asset
 		cpuStake eos.Asset
 		netStake eos.Asset
 		xfer     eos.Asset
+		policy   string
 	}{
 		{
 			eos.NewEOSAsset(10000), // 1.0 EOS
 			eos.NewEOSAsset(2500),
 			eos.NewEOSAsset(2500),
 			eos.NewEOSAsset(5000), // 0.5 EOS
+			"",
 		},
 		{
 			eos.NewEOSAsset(100000), // 10.0 EOS
 			eos.NewEOSAsset(2500),   // 0.25 EOS
 			eos.NewEOSAsset(2500),   // 0.25 EOS
 			eos.NewEOSAsset(95000),  // 9.5 EOS
+			"",
 		},
 		{
 			eos.NewEOSAsset(105000), // 10.5 EOS
 			eos.NewEOSAsset(2500),   // 0.25 EOS
 			eos.NewEOSAsset(2500),   // 0.25 EOS
 			eos.NewEOSAsset(100000), // 10.0 EOS
+			"",
 		},
 		{
 			eos.NewEOSAsset(107000), // 10.7 EOS
 			eos.NewEOSAsset(3500),   // 0.35 EOS
 			eos.NewEOSAsset(3500),   // 0.35 EOS
 			eos.NewEOSAsset(100000), // 10.0 EOS
+			"",
 		},
 		{
 			eos.NewEOSAsset(120000), // 12.0 EOS
 			eos.NewEOSAsset(10000),  // 0.25 + 0.75 EOS
 			eos.NewEOSAsset(10000),  // 0.25 + 0.75 EOS
 			eos.NewEOSAsset(100000), // 10.0 EOS
+			"",
 		},
 		{
 			eos.NewEOSAsset(99990000), // 9999.0 EOS
 			eos.NewEOSAsset(49945000), // 4994.5 EOS
 			eos.NewEOSAsset(49945000), // 4994.5 EOS, 10.0 EOS remaining :) yessir!
 			eos.NewEOSAsset(100000),   // 10.0 EOS
+			"",
+		},
+		{
+			eos.NewEOSAsset(4000), // 0.4 EOS, under the minimum stakes
+			eos.NewEOSAsset(0),
+			eos.NewEOSAsset(0),
+			eos.NewEOSAsset(0),
+			"",
+		},
+		{
+			eos.NewEOSAsset(4000), // 0.4 EOS
+			eos.NewEOSAsset(0),
+			eos.NewEOSAsset(0),
+			eos.NewEOSAsset(4000),
+			`{"small_balances": "liquid"}`,
+		},
+		{
+			eos.NewEOSAsset(4001), // 0.4001 EOS
+			eos.NewEOSAsset(2000),
+			eos.NewEOSAsset(2001),
+			eos.NewEOSAsset(0),
+			`{"small_balances": "stake"}`,
+		},
+		{
+			eos.NewEOSAsset(120000), // 12.0 EOS
+			eos.NewEOSAsset(11000),  // 1.0 + 0.1 EOS
+			eos.NewEOSAsset(14000),  // 1.0 + 0.4 EOS
+			eos.NewEOSAsset(95000),  // 9.5 EOS
+			`{"minimum_cpu_stake": "1.0000 EOS", "minimum_net_stake": "1.0000 EOS", "liquid_amount": "9.5000 EOS", "cpu_ratio": 0.2}`,
+		},
+		{
+			eos.NewEOSAsset(120000), // 12.0 EOS
+			eos.NewEOSAsset(2500),   // 0.25 EOS
+			eos.NewEOSAsset(117500), // 0.25 + 11.5 EOS
+			eos.NewEOSAsset(0),
+			`{"liquid_amount": "0.0000 EOS", "cpu_ratio": 0}`,
 		},
 	}
 
 	for idx, test := range tests {
-		cpuStake, netStake, xfer := splitSnapshotStakes(test.balance)
+		policy, err := (&OpSnapshotCreateAccounts{Distribution: json.RawMessage(test.policy)}).Policy(eos.EOSSymbol)
+		require.NoError(t, err, fmt.Sprintf("idx=%d", idx))
+
+		cpuStake, netStake, xfer := splitSnapshotStakes(policy, test.balance)
 		assert.Equal(t, test.cpuStake, cpuStake, fmt.Sprintf("idx=%d", idx))
 		assert.Equal(t, test.netStake, netStake, fmt.Sprintf("idx=%d", idx))
 		assert.Equal(t, test.xfer, xfer, fmt.Sprintf("idx=%d", idx))
 	}
 }
+
+func TestSnapshotDelegationAmountsCoreSymbol(t *testing.T) {
+	symbol := eos.Symbol{Precision: 8, Symbol: "XYZ"}
+
+	policy, err := (&OpSnapshotCreateAccounts{}).Policy(symbol)
+	require.NoError(t, err)
+
+	balance, err := NewAssetFromString("12.0", symbol)
+	require.NoError(t, err)
+
+	cpuStake, netStake, xfer := splitSnapshotStakes(policy, balance)
+	assert.Equal(t, "1.00000000 XYZ", cpuStake.String())
+	assert.Equal(t, "1.00000000 XYZ", netStake.String())
+	assert.Equal(t, "10.00000000 XYZ", xfer.String())
+
+	_, err = (&OpSnapshotCreateAccounts{Distribution: json.RawMessage(`{"liquid_amount": "10.0000 EOS"}`)}).Policy(symbol)
+	assert.EqualError(t, err, "liquid_amount 10.0000 EOS not in core symbol 8,XYZ")
+}
+
+func TestNewAssetFromString(t *testing.T) {
+	tlos := eos.Symbol{Precision: 4, Symbol: "TLOS"}
+
+	tests := []struct {
+		in     string
+		symbol eos.Symbol
+		out    string
+		err    string
+	}{
+		{"10", tlos, "10.0000 TLOS", ""},
+		{"10.5", tlos, "10.5000 TLOS", ""},
+		{"10.5000 TLOS", tlos, "10.5000 TLOS", ""},
+		{"0.00000001", eos.Symbol{Precision: 8, Symbol: "BTC"}, "0.00000001 BTC", ""},
+		{"42", eos.Symbol{Precision: 0, Symbol: "PTS"}, "42 PTS", ""},
+		{"10.5000 EOS", tlos, "", `amount "10.5000 EOS" not in core symbol 4,TLOS`},
+		{"10.50001", tlos, "", "TLOS has only 4 decimals"},
+		{"1.2.3", tlos, "", "cannot have two . in amount"},
+		{"", tlos, "", "cannot be an empty string"},
+	}
+
+	for _, test := range tests {
+		asset, err := NewAssetFromString(test.in, test.symbol)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.out, asset.String(), test.in)
+	}
+}
+
+func TestOpVoteProducer(t *testing.T) {
+	b := &BIOS{}
+
+	op := &OpVoteProducer{
+		Voters:     []eos.AccountName{"alice"},
+		VoterCount: 2,
+		ProducerSets: [][]eos.AccountName{
+			{"prodb", "proda"},
+			{"prodc"},
+		},
+		Stake: "10",
+	}
+
+	acts, err := op.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 9) // delegatebw, voteproducer and a boundary per voter
+
+	vote := acts[1].ActionData.Data.(system.VoteProducer)
+	assert.Equal(t, eos.AccountName("alice"), vote.Voter)
+	assert.Equal(t, []eos.AccountName{"proda", "prodb"}, vote.Producers)
+	assert.Nil(t, acts[2])
+
+	vote = acts[4].ActionData.Data.(system.VoteProducer)
+	assert.Equal(t, eos.AccountName("voteraaaaaaa"), vote.Voter)
+	assert.Equal(t, []eos.AccountName{"prodc"}, vote.Producers)
+
+	stake := acts[6].ActionData.Data.(system.DelegateBW)
+	assert.Equal(t, eos.AccountName("voteraaaaaab"), stake.From)
+	assert.Equal(t, "10.0000 EOS", stake.StakeCPU.String())
+	assert.False(t, bool(stake.Transfer))
+
+	_, err = (&OpVoteProducer{Voters: []eos.AccountName{"alice"}, ProducerSets: [][]eos.AccountName{{"proda", "proda"}}}).Actions(b)
+	assert.EqualError(t, err, `producer_sets[0]: "proda" listed twice`)
+
+	_, err = (&OpVoteProducer{ProducerSets: [][]eos.AccountName{{"proda"}}}).Actions(b)
+	assert.EqualError(t, err, "no voters, list some in `voters` or set `voter_count`")
+
+	acts, err = (&OpVoteProducer{Voters: []eos.AccountName{"alice"}, Proxy: "proxy"}).Actions(b)
+	require.NoError(t, err)
+	vote = acts[0].ActionData.Data.(system.VoteProducer)
+	assert.Equal(t, eos.AccountName("proxy"), vote.Proxy)
+	assert.Len(t, vote.Producers, 0)
+}
+
+func TestOpRegProducer(t *testing.T) {
+	ephemeral, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	b := &BIOS{EphemeralPublicKey: ephemeral.PublicKey()}
+
+	op := &OpRegProducer{Producers: []*producerRegistration{
+		{Account: "proda", URL: "https://a.example.com", Location: 1},
+		{Account: "prodb", BlockSigningKey: "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"},
+	}}
+
+	acts, err := op.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 4)
+
+	reg := acts[0].ActionData.Data.(system.RegProducer)
+	assert.Equal(t, ephemeral.PublicKey(), reg.ProducerKey)
+	assert.Equal(t, "https://a.example.com", reg.URL)
+	assert.Equal(t, uint16(1), reg.Location)
+
+	reg = acts[2].ActionData.Data.(system.RegProducer)
+	assert.Equal(t, "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV", reg.ProducerKey.String())
+
+	op.Producers[0].BlockSigningKey = "invalid"
+	_, err = op.Actions(b)
+	assert.Error(t, err)
+}
+
+func TestOpUpdateAuth(t *testing.T) {
+	ephemeral, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	b := &BIOS{EphemeralPublicKey: ephemeral.PublicKey()}
+
+	var bootSeq *BootSeq
+	require.NoError(t, yamlUnmarshal([]byte(`
+boot_sequence:
+- op: system.updateauth
+  label: Consortium multisig on treasury
+  data:
+    account: treasury
+    permission: active
+    parent: owner
+    auth:
+      threshold: 3
+      keys:
+      - key: EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV
+        weight: 1
+      - key: ephemeral
+        weight: 1
+      accounts:
+      - permission: {actor: memberb, permission: active}
+        weight: 1
+      - permission: {actor: membera, permission: active}
+        weight: 1
+      waits:
+      - wait_sec: 3600
+        weight: 1
+- op: system.linkauth
+  data:
+    account: treasury
+    code: eosio.token
+    type: transfer
+    requirement: payments
+- op: system.unlinkauth
+  data:
+    account: treasury
+    code: eosio.token
+    type: transfer
+`), &bootSeq))
+	require.Len(t, bootSeq.BootSequence, 3)
+
+	acts, err := bootSeq.BootSequence[0].Data.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "treasury", Permission: "active"}}, acts[0].Authorization)
+
+	update := acts[0].ActionData.Data.(system.UpdateAuth)
+	assert.Equal(t, eos.PermissionName("owner"), update.Parent)
+	assert.Equal(t, uint32(3), update.Auth.Threshold)
+	require.Len(t, update.Auth.Keys, 2)
+	assert.Contains(t, []string{update.Auth.Keys[0].PublicKey.String(), update.Auth.Keys[1].PublicKey.String()}, ephemeral.PublicKey().String())
+	assert.Equal(t, eos.AccountName("membera"), update.Auth.Accounts[0].Permission.Actor)
+	assert.Equal(t, eos.AccountName("memberb"), update.Auth.Accounts[1].Permission.Actor)
+	assert.Equal(t, uint32(3600), update.Auth.Waits[0].WaitSec)
+
+	acts, err = bootSeq.BootSequence[1].Data.Actions(b)
+	require.NoError(t, err)
+	link := acts[0].ActionData.Data.(system.LinkAuth)
+	assert.Equal(t, eos.PermissionName("payments"), link.Requirement)
+
+	acts, err = bootSeq.BootSequence[2].Data.Actions(b)
+	require.NoError(t, err)
+	assert.Equal(t, eos.ActionName("unlinkauth"), acts[0].Name)
+
+	op := bootSeq.BootSequence[0].Data.(*OpUpdateAuth)
+	op.Auth.Threshold = 6
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, "treasury@active: threshold 6 unreachable, weights only add up to 5")
+
+	op.Auth.Threshold = 1
+	op.Auth.Keys[0].Key = "ephemeral"
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, "treasury@active: key "+ephemeral.PublicKey().String()+" listed twice")
+
+	_, err = (&OpUpdateAuth{Account: "treasury", Permission: "payments", Auth: AuthorityDefinition{Threshold: 1}}).Actions(b)
+	assert.EqualError(t, err, "missing parent of treasury@payments")
+}
+
+func TestOpResignAccounts(t *testing.T) {
+	acts, err := (&OpResignAccounts{Accounts: []eos.AccountName{"eosio", "eosio.msig"}}).Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, 5)
+	assert.Nil(t, acts[4])
+
+	owner := acts[1].ActionData.Data.(system.UpdateAuth)
+	assert.Equal(t, eos.AccountName("eosio.msig"), owner.Account)
+	assert.Equal(t, eos.PermissionName("owner"), owner.Permission)
+	assert.Equal(t, eos.PermissionName(""), owner.Parent)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "eosio.msig", Permission: "owner"}}, acts[1].Authorization)
+	assert.Equal(t, eos.AccountName("eosio"), owner.Auth.Accounts[0].Permission.Actor)
+
+	active := acts[2].ActionData.Data.(system.UpdateAuth)
+	assert.Equal(t, eos.AccountName("eosio"), active.Account)
+	assert.Equal(t, eos.AccountName("eosio.prods"), active.Auth.Accounts[0].Permission.Actor)
+}
+
+func TestOpSetParams(t *testing.T) {
+	params, err := (&OpSetParams{}).Parameters()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBlockchainParameters(), *params)
+
+	op := &OpSetParams{Params: json.RawMessage(`{"max_block_cpu_usage": 400000, "max_transaction_cpu_usage": 300000, "max_block_net_usage": 2097152}`)}
+	acts, err := op.Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	assert.Equal(t, eos.ActionName("setparams"), acts[0].Name)
+
+	set := acts[0].ActionData.Data.(SetParams)
+	assert.Equal(t, uint32(400000), set.Params.MaxBlockCPUUsage)
+	assert.Equal(t, eos.Uint64(2097152), set.Params.MaxBlockNetUsage)
+	assert.Equal(t, uint16(6), set.Params.MaxAuthorityDepth)
+
+	tests := []struct {
+		params string
+		err    string
+	}{
+		{`{"target_block_cpu_usage_pct": 10001}`, "target_block_cpu_usage_pct can't exceed 100% (10000)"},
+		{`{"target_block_net_usage_pct": 9}`, "target_block_net_usage_pct must be at least 0.1% (10)"},
+		{`{"max_transaction_cpu_usage": 200000}`, "max_transaction_cpu_usage must be less than max_block_cpu_usage"},
+		{`{"max_transaction_net_usage": 10000}`, "max_transaction_net_usage must be at least 10240 above base_per_transaction_net_usage"},
+		{`{"context_free_discount_net_usage_num": 101}`, "context_free_discount_net_usage_num can't exceed context_free_discount_net_usage_den"},
+		{`{"max_authority_depth": 0}`, "max_authority_depth must be at least 1"},
+	}
+	for _, test := range tests {
+		_, err := (&OpSetParams{Params: json.RawMessage(test.params)}).Actions(&BIOS{})
+		assert.EqualError(t, err, "params: "+test.err, test.params)
+	}
+}
+
+func TestOpSetALimits(t *testing.T) {
+	op := &OpSetALimits{Accounts: []*accountLimits{
+		{Account: "eosio.msig", RAMBytes: -1, NetWeight: -1, CPUWeight: -1},
+		{Account: "member1", RAMBytes: 65536, NetWeight: 100, CPUWeight: 100},
+	}}
+
+	acts, err := op.Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, 2)
+	assert.Equal(t, eos.ActionName("setalimits"), acts[0].Name)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "eosio", Permission: "active"}}, acts[0].Authorization)
+	assert.Equal(t, int64(65536), acts[1].ActionData.Data.(system.Setalimits).RAMBytes)
+
+	op.Accounts[1].CPUWeight = -2
+	_, err = op.Actions(&BIOS{})
+	assert.EqualError(t, err, `limits of "member1" must be -1 (unlimited) or positive`)
+
+	acts, err = (&OpSetRAMRate{BytesPerBlock: 1024}).Actions(&BIOS{})
+	require.NoError(t, err)
+	assert.Equal(t, eos.ActionName("setramrate"), acts[0].Name)
+}
+
+func TestOpInitSystem(t *testing.T) {
+	b := &BIOS{BootSequence: &BootSeq{CoreSymbol: &Symbol{Precision: 4, Symbol: "TLOS"}}}
+
+	acts, err := (&OpInitSystem{}).Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 1)
+	assert.Equal(t, eos.ActionName("init"), acts[0].Name)
+
+	data, err := eos.MarshalBinary(acts[0].ActionData.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "00"+"04544c4f53000000", hex.EncodeToString(data)) // version 0, then 4,TLOS
+
+	_, err = (&OpInitSystem{Version: 1}).Actions(b)
+	assert.EqualError(t, err, "unsupported version 1, eosio.system only knows 0")
+}
+
+func TestOpBidName(t *testing.T) {
+	op := &OpBidName{Bids: []*nameBid{
+		{Bidder: "alice", NewName: "dapp", Bid: "10"},
+	}}
+
+	acts, err := op.Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, 2)
+	bid := acts[0].ActionData.Data.(system.Bidname)
+	assert.Equal(t, eos.AccountName("dapp"), bid.Newname)
+	assert.Equal(t, "10.0000 EOS", bid.Bid.String())
+
+	op.Bids[0].NewName = "my.dapp"
+	_, err = op.Actions(&BIOS{})
+	assert.EqualError(t, err, `"my.dapp" isn't a premium name, only names under 12 characters without a dot are auctioned`)
+
+	op.Bids[0].NewName = "dapp"
+	op.Bids[0].Bid = "0"
+	_, err = op.Actions(&BIOS{})
+	assert.EqualError(t, err, `bid of alice on "dapp" must be positive`)
+}
+
+func TestOpBuyREX(t *testing.T) {
+	acts, err := (&OpBuyREX{Deposits: []*rexDeposit{{Account: "alice", Amount: "1000"}}}).Actions(&BIOS{})
+	require.NoError(t, err)
+	require.Len(t, acts, 3)
+
+	assert.Equal(t, eos.ActionName("deposit"), acts[0].Name)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "alice", Permission: "active"}}, acts[0].Authorization)
+	assert.Equal(t, eos.ActionName("buyrex"), acts[1].Name)
+	assert.Equal(t, "1000.0000 EOS", acts[1].ActionData.Data.(BuyREX).Amount.String())
+	assert.Nil(t, acts[2])
+
+	acts, err = (&OpSetREX{Balance: "50000"}).Actions(&BIOS{})
+	require.NoError(t, err)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "eosio", Permission: "active"}}, acts[0].Authorization)
+}
+
+func TestOpDistributeToken(t *testing.T) {
+	cachePath, err := ioutil.TempDir("", "eos-bios-distribute")
+	require.NoError(t, err)
+	defer os.RemoveAll(cachePath)
+
+	b := &BIOS{CachePath: cachePath}
+	require.NoError(t, yamlUnmarshal([]byte(`
+contents:
+- name: airdrop.csv
+  url: file://airdrop.csv
+boot_sequence:
+- op: token.create
+  data: {contract: sidechaintok, account: issuer, amount: "1000.0000 XYZ"}
+- op: token.issue
+  data: {contract: sidechaintok, issuer: issuer, account: issuer, amount: "100.0000 XYZ"}
+- op: token.issue
+  data: {account: eosio, amount: "500.0000 XYZ"}
+- op: token.distribute
+  data:
+    contract: sidechaintok
+    from: issuer
+    symbol: 4,XYZ
+    contents_ref: airdrop.csv
+    memo: airdrop
+    transfers_per_transaction: 2
+- op: token.issue
+  data: {contract: sidechaintok, issuer: issuer, account: issuer, amount: "500.0000 XYZ"}
+- op: token.transfer
+  data: {contract: sidechaintok, from: issuer, to: alice, amount: "550.0000 XYZ"}
+- op: token.distribute
+  data: {contract: sidechaintok, from: issuer, symbol: "4,XYZ", contents_ref: airdrop.csv}
+- op: msig.propose
+  data:
+    proposer: issuer
+    proposal_name: airdrop
+    requested: [{actor: issuer, permission: active}]
+    expiration: "2019-01-01T00:00:00"
+    steps:
+    - op: token.distribute
+      data: {contract: sidechaintok, from: issuer, symbol: "4,XYZ", contents_ref: airdrop.csv}
+- op: token.issue
+  data: {contract: sidechaintok, issuer: issuer, account: issuer, amount: "1000.0000 XYZ"}
+`), &b.BootSequence))
+	require.NoError(t, b.writeToCache("file://airdrop.csv", []byte("account,amount\nalice,10\nbob,20.5\ncarol,0.0001\n")))
+
+	acts, err := b.BootSequence.BootSequence[0].Data.Actions(b)
+	require.NoError(t, err)
+	assert.Equal(t, eos.AccountName("sidechaintok"), acts[0].Account)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "sidechaintok", Permission: "active"}}, acts[0].Authorization)
+
+	acts, err = b.BootSequence.BootSequence[1].Data.Actions(b)
+	require.NoError(t, err)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "issuer", Permission: "active"}}, acts[0].Authorization)
+
+	op := b.BootSequence.BootSequence[3].Data.(*OpDistributeToken)
+	acts, err = op.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 5) // two transfers per transaction
+
+	assert.Equal(t, eos.AccountName("sidechaintok"), acts[0].Account)
+	assert.Equal(t, []eos.PermissionLevel{{Actor: "issuer", Permission: "active"}}, acts[0].Authorization)
+	transfer := acts[1].ActionData.Data.(token.Transfer)
+	assert.Equal(t, eos.AccountName("bob"), transfer.To)
+	assert.Equal(t, "20.5000 XYZ", transfer.Quantity.String())
+	assert.Equal(t, "airdrop", transfer.Memo)
+	assert.Nil(t, acts[2])
+	assert.Nil(t, acts[4])
+
+	// The transfer and the first distribution leave 600 - 550 - 30.5001
+	// XYZ to the issuer.
+	_, err = b.BootSequence.BootSequence[6].Data.Actions(b)
+	assert.EqualError(t, err, "airdrop.csv distributes 30.5001 XYZ, more than the 19.4999 XYZ issuer holds before it")
+
+	// A proposed distribution only counts the steps before the
+	// proposal, not the issue after it.
+	proposed := b.BootSequence.BootSequence[7].Data.(*OpMsigPropose).Steps[0].Data.(*OpDistributeToken)
+	_, err = proposed.Actions(b)
+	assert.EqualError(t, err, "airdrop.csv distributes 30.5001 XYZ, more than the -11.0002 XYZ issuer holds before it")
+
+	// Only what's issued on the same contract, before the step, counts.
+	require.NoError(t, b.writeToCache("file://airdrop.csv", []byte("alice,100\nbob,0.0001\n")))
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, "airdrop.csv distributes 100.0001 XYZ, more than the 100.0000 XYZ issuer holds before it")
+
+	require.NoError(t, b.writeToCache("file://airdrop.csv", []byte("alice,10\nBob,1\n")))
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, `airdrop.csv: line 2: account name "Bob" has invalid character 'B', only a-z, 1-5 and . are allowed`)
+
+	require.NoError(t, b.writeToCache("file://airdrop.csv", []byte("alice,10.00001\n")))
+	_, err = op.Actions(b)
+	assert.EqualError(t, err, "airdrop.csv: line 1: XYZ has only 4 decimals")
+}
@@ -0,0 +1,288 @@
+package bios
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/eoscanada/eos-go"
+)
+
+// Role is what a participant does during the launch.
+type Role int
+
+const (
+	RoleJoiner Role = iota
+	RoleBootNode
+)
+
+func (r Role) String() string {
+	if r == RoleBootNode {
+		return "boot node"
+	}
+	return "joiner"
+}
+
+// RoleOf finds the role of `account` in `mesh`: the most trusted
+// participant boots, everyone else joins and validates.
+func RoleOf(mesh *Mesh, account eos.AccountName) Role {
+	if bootNode := mesh.BootNode(); bootNode != nil && bootNode.Account == account {
+		return RoleBootNode
+	}
+	return RoleJoiner
+}
+
+// Orchestrator coordinates a launch through the `eosio.disco` contract
+// of a seed network: it keeps `MyDiscovery` published, waits for the
+// `seed_network_launch_block`, then boots or joins the target network
+// depending on the role the mesh gives this participant.
+type Orchestrator struct {
+	BIOS        *BIOS
+	Disco       *disco.Client
+	MyDiscovery *disco.Discovery
+
+	StaleAfter     time.Duration
+	PollInterval   time.Duration
+	GenesisTimeout time.Duration
+}
+
+func NewOrchestrator(b *BIOS, client *disco.Client, myDiscovery *disco.Discovery) *Orchestrator {
+	return &Orchestrator{
+		BIOS:           b,
+		Disco:          client,
+		MyDiscovery:    myDiscovery,
+		StaleAfter:     30 * time.Minute,
+		PollInterval:   5 * time.Second,
+		GenesisTimeout: 30 * time.Minute,
+	}
+}
+
+func (o *Orchestrator) Run() error {
+	myAccount := o.MyDiscovery.SeedNetworkAccountName
+	launchBlock := o.MyDiscovery.SeedNetworkLaunchBlock
+	if launchBlock == 0 {
+		return fmt.Errorf("discovery file has no `seed_network_launch_block`")
+	}
+
+	if err := o.publishDiscovery(); err != nil {
+		return err
+	}
+
+	// The boot node is only known at the launch block, so everyone
+	// clears the genesis of a previous launch beforehand: joiners then
+	// only ever read the genesis of this one.
+	if err := o.clearGenesis(); err != nil {
+		return err
+	}
+
+	if err := o.waitLaunchBlock(launchBlock); err != nil {
+		return err
+	}
+
+	launchTime, err := o.launchTime(launchBlock)
+	if err != nil {
+		return err
+	}
+
+	mesh, err := o.launchMesh(launchTime)
+	if err != nil {
+		return err
+	}
+
+	role := RoleOf(mesh, myAccount)
+	bootNode := mesh.BootNode()
+
+	o.BIOS.Log.Printf("Launch block %d reached, with %d participants.\n", launchBlock, len(mesh.Peers))
+	o.BIOS.Log.Printf("Boot node is %q, we are %q: %s.\n", bootNode.Account, myAccount, role)
+
+	if role == RoleBootNode {
+		o.BIOS.PublishGenesis = func(genesisJSON string) error {
+			return o.Disco.PublishGenesis(myAccount, genesisJSON, []string{o.MyDiscovery.TargetP2PAddress})
+		}
+		return o.BIOS.Boot()
+	}
+
+	genesis, err := o.waitGenesis(bootNode.Account, launchTime)
+	if err != nil {
+		return err
+	}
+
+	return o.BIOS.Join(genesis.GenesisJSON, genesis.InitialP2PAddresses, true)
+}
+
+func (o *Orchestrator) publishDiscovery() error {
+	if err := o.Disco.PublishDiscovery(o.MyDiscovery.SeedNetworkAccountName, o.MyDiscovery); err != nil {
+		return fmt.Errorf("publishing discovery: %s", err)
+	}
+	return nil
+}
+
+// waitLaunchBlock polls the seed network until its head block reaches
+// `launchBlock`. The discovery file is republished regularly, so we
+// don't appear stale to others meanwhile.
+func (o *Orchestrator) waitLaunchBlock(launchBlock uint64) error {
+	o.BIOS.Log.Printf("Waiting for seed network to reach launch block %d", launchBlock)
+
+	lastPublish := time.Now()
+	for {
+		info, err := o.Disco.API.GetInfo()
+		if err != nil {
+			o.BIOS.Log.Debugf("seed network error: %s\n", err)
+			o.BIOS.Log.Printf("e")
+		} else if uint64(info.HeadBlockNum) >= launchBlock {
+			o.BIOS.Log.Println(" reached!")
+			return nil
+		} else {
+			o.BIOS.Log.Debugf("seed network at block %d\n", info.HeadBlockNum)
+			o.BIOS.Log.Printf(".")
+		}
+
+		if time.Since(lastPublish) > o.StaleAfter/3 {
+			if err := o.publishDiscovery(); err != nil {
+				return err
+			}
+			lastPublish = time.Now()
+		}
+
+		time.Sleep(o.PollInterval)
+	}
+}
+
+// launchTime is the timestamp of the launch block, a time every
+// participant agrees on.
+func (o *Orchestrator) launchTime(launchBlock uint64) (time.Time, error) {
+	block, err := o.Disco.API.GetBlockByNum(uint32(launchBlock))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting launch block %d: %s", launchBlock, err)
+	}
+	return block.Timestamp.Time, nil
+}
+
+// launchMesh builds the mesh out of the participants active at
+// `launchTime`, and aiming at the same launch block as us.
+func (o *Orchestrator) launchMesh(launchTime time.Time) (*Mesh, error) {
+	rows, err := o.Disco.ListDiscovery()
+	if err != nil {
+		return nil, fmt.Errorf("listing discovery: %s", err)
+	}
+
+	discoveries := o.launchParticipants(rows, launchTime)
+	if len(discoveries) == 0 {
+		return nil, fmt.Errorf("no participant was active at the launch block")
+	}
+
+	mesh, err := NewMesh(discoveries)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range mesh.Warnings {
+		o.BIOS.Log.Printf("WARNING: %s\n", warning)
+	}
+	return mesh, nil
+}
+
+// launchParticipants keeps the discovery `rows` aiming at our launch
+// block, and last updated within `StaleAfter` before `launchTime`.
+// Rows are judged against the launch block rather than our clock, and
+// rows updated after it are left out, so every participant builds the
+// same mesh whenever it reads them. Our own row counts like the
+// others.
+func (o *Orchestrator) launchParticipants(rows []*disco.DiscoveryRow, launchTime time.Time) (out []*disco.Discovery) {
+	for _, row := range rows {
+		if row.Content == nil {
+			continue
+		}
+		if row.UpdatedAt.After(launchTime) || row.Stale(launchTime, o.StaleAfter) {
+			o.BIOS.Log.Debugf("skipping %q, updated at %s\n", row.ID, row.UpdatedAt.Time)
+			continue
+		}
+		if row.Content.SeedNetworkLaunchBlock != o.MyDiscovery.SeedNetworkLaunchBlock {
+			o.BIOS.Log.Debugf("skipping %q, launching at block %d\n", row.ID, row.Content.SeedNetworkLaunchBlock)
+			continue
+		}
+
+		row.Content.SeedNetworkAccountName = row.ID
+		out = append(out, row.Content)
+	}
+	return out
+}
+
+// clearGenesis deletes the genesis we published for a previous
+// launch, if any.
+func (o *Orchestrator) clearGenesis() error {
+	myAccount := o.MyDiscovery.SeedNetworkAccountName
+	genesis, err := o.Disco.GetGenesis(myAccount)
+	if err != nil {
+		return fmt.Errorf("reading our genesis: %s", err)
+	}
+	if genesis == nil {
+		return nil
+	}
+
+	o.BIOS.Log.Println("Deleting the genesis of a previous launch")
+	if err := o.Disco.DeleteGenesis(myAccount); err != nil {
+		return fmt.Errorf("deleting our genesis: %s", err)
+	}
+	return nil
+}
+
+// waitGenesis polls the seed network until `bootNode` publishes the
+// genesis of this launch, for `GenesisTimeout` at most.
+func (o *Orchestrator) waitGenesis(bootNode eos.AccountName, launchTime time.Time) (*disco.GenesisRow, error) {
+	o.BIOS.Log.Printf("Waiting for %q to publish the genesis", bootNode)
+
+	deadline := time.Now().Add(o.GenesisTimeout)
+	for {
+		genesis, err := o.Disco.GetGenesis(bootNode)
+		if err != nil {
+			o.BIOS.Log.Debugf("seed network error: %s\n", err)
+			o.BIOS.Log.Printf("e")
+		} else if genesis == nil {
+			o.BIOS.Log.Printf(".")
+		} else if err := checkGenesisLaunch(genesis, launchTime); err != nil {
+			o.BIOS.Log.Debugf("ignoring genesis: %s\n", err)
+			o.BIOS.Log.Printf("s")
+		} else {
+			o.BIOS.Log.Println(" got it!")
+			return genesis, nil
+		}
+
+		if time.Now().After(deadline) {
+			o.BIOS.Log.Println(" timed out")
+			return nil, fmt.Errorf("%q didn't publish the genesis within %s", bootNode, o.GenesisTimeout)
+		}
+
+		time.Sleep(o.PollInterval)
+	}
+}
+
+// genesisClockSkew is how far before the launch block the boot
+// node's clock can date the genesis.
+const genesisClockSkew = time.Minute
+
+// checkGenesisLaunch makes sure `genesis` was created for the launch
+// at `launchTime`: the boot node generates it once the launch block is
+// reached, so the genesis of a previous launch is older.
+func checkGenesisLaunch(genesis *disco.GenesisRow, launchTime time.Time) error {
+	var content *GenesisJSON
+	if err := json.Unmarshal([]byte(genesis.GenesisJSON), &content); err != nil || content == nil {
+		return fmt.Errorf("invalid genesis_json of %q", genesis.ID)
+	}
+
+	var initialTime time.Time
+	var err error
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04:05.000"} {
+		if initialTime, err = time.Parse(layout, content.InitialTimestamp); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid initial_timestamp %q in the genesis of %q", content.InitialTimestamp, genesis.ID)
+	}
+
+	if initialTime.Before(launchTime.Add(-genesisClockSkew)) {
+		return fmt.Errorf("genesis of %q starts at %s, before the launch block at %s, it is from a previous launch", genesis.ID, initialTime, launchTime)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package bios
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleOf(t *testing.T) {
+	mesh, err := NewMesh([]*disco.Discovery{
+		{SeedNetworkAccountName: "alice", SeedNetworkPeers: []*disco.PeerLink{{Account: "bob", Weight: 100}}},
+		{SeedNetworkAccountName: "bob", SeedNetworkPeers: []*disco.PeerLink{{Account: "carol", Weight: 50}}},
+		{SeedNetworkAccountName: "carol", SeedNetworkPeers: []*disco.PeerLink{{Account: "bob", Weight: 100}}},
+		{SeedNetworkAccountName: "dave"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, RoleBootNode, RoleOf(mesh, "bob"))
+	assert.Equal(t, RoleJoiner, RoleOf(mesh, "carol"))
+	assert.Equal(t, RoleJoiner, RoleOf(mesh, "unknown"))
+	assert.Equal(t, "boot node", RoleBootNode.String())
+}
+
+func TestCheckGenesisLaunch(t *testing.T) {
+	launchTime := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	genesis := func(initialTimestamp string) *disco.GenesisRow {
+		return &disco.GenesisRow{ID: "bob", GenesisJSON: `{"initial_timestamp": "` + initialTimestamp + `", "initial_key": "EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}`}
+	}
+
+	assert.NoError(t, checkGenesisLaunch(genesis("2019-06-01T12:00:30"), launchTime))
+	assert.NoError(t, checkGenesisLaunch(genesis("2019-06-01T11:59:30.000"), launchTime), "the boot node's clock can be a bit behind")
+	assert.EqualError(t, checkGenesisLaunch(genesis("2019-05-20T08:00:00"), launchTime), `genesis of "bob" starts at 2019-05-20 08:00:00 +0000 UTC, before the launch block at 2019-06-01 12:00:00 +0000 UTC, it is from a previous launch`)
+	assert.EqualError(t, checkGenesisLaunch(genesis("yesterday"), launchTime), `invalid initial_timestamp "yesterday" in the genesis of "bob"`)
+	assert.EqualError(t, checkGenesisLaunch(&disco.GenesisRow{ID: "bob", GenesisJSON: "{"}, launchTime), `invalid genesis_json of "bob"`)
+}
+
+func TestLaunchParticipants(t *testing.T) {
+	launchTime := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	row := func(account string, updatedAt time.Time, launchBlock uint64) *disco.DiscoveryRow {
+		return &disco.DiscoveryRow{
+			ID:        eos.AccountName(account),
+			Content:   &disco.Discovery{SeedNetworkLaunchBlock: launchBlock},
+			UpdatedAt: eos.JSONTime{Time: updatedAt},
+		}
+	}
+
+	o := &Orchestrator{
+		BIOS:        &BIOS{},
+		MyDiscovery: &disco.Discovery{SeedNetworkAccountName: "alice", SeedNetworkLaunchBlock: 1000},
+		StaleAfter:  30 * time.Minute,
+	}
+
+	participants := o.launchParticipants([]*disco.DiscoveryRow{
+		row("alice", launchTime.Add(-10*time.Minute), 1000),
+		row("bob", launchTime, 1000),
+		row("carol", launchTime.Add(-30*time.Minute), 1000),
+		row("dave", launchTime.Add(-31*time.Minute), 1000),
+		row("eve", launchTime.Add(time.Second), 1000),
+		row("frank", launchTime.Add(-time.Minute), 2000),
+		{ID: "grace", UpdatedAt: eos.JSONTime{Time: launchTime}},
+	}, launchTime)
+
+	var accounts []eos.AccountName
+	for _, participant := range participants {
+		accounts = append(accounts, participant.SeedNetworkAccountName)
+	}
+	// Dave went stale before the launch block, and Eve updated after
+	// it: whoever reads the rows, and whenever, leaves them out.
+	assert.Equal(t, []eos.AccountName{"alice", "bob", "carol"}, accounts)
+}
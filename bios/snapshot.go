@@ -1,14 +1,100 @@
 package bios
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/eoscanada/eos-go"
 	"github.com/eoscanada/eos-go/ecc"
 )
 
+// Snapshot fields, as named in headers, JSON-lines keys and in the
+// `columns` mapping of a `SnapshotFormat`.
+const (
+	SnapshotEthereumAddress = "ethereum_address"
+	SnapshotAccountName     = "account_name"
+	SnapshotPublicKey       = "public_key"
+	SnapshotBalance         = "balance"
+)
+
+// SnapshotFormat describes the layout of a snapshot file. The zero
+// value reads a CSV file without header, with the columns in the
+// historical order.
+type SnapshotFormat struct {
+	// Type is one of `csv` (the default), `tsv` or `jsonl`.
+	Type string `json:"type"`
+
+	// Header tells that the first line of a `csv` or `tsv` file names
+	// its columns. Without a `columns` mapping, those names are the
+	// snapshot field names.
+	Header bool `json:"header"`
+
+	// Columns maps snapshot fields to a column, either by its 1-based
+	// position, or by the name found in the header or JSON-lines keys.
+	Columns map[string]SnapshotColumn `json:"columns"`
+}
+
+// SnapshotColumn references a column of a snapshot file, by its
+// 1-based position (`balance: 3`) or by name (`balance: amount`).
+type SnapshotColumn struct {
+	Position int
+	Name     string
+}
+
+func (c *SnapshotColumn) UnmarshalJSON(data []byte) error {
+	var position int
+	if err := json.Unmarshal(data, &position); err == nil {
+		if position < 1 {
+			return fmt.Errorf("column position %d invalid, positions start at 1", position)
+		}
+		*c = SnapshotColumn{Position: position}
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("column should be a position or a name: %s", err)
+	}
+
+	*c = SnapshotColumn{Name: name}
+	return nil
+}
+
+func (c SnapshotColumn) MarshalJSON() ([]byte, error) {
+	if c.Name != "" {
+		return json.Marshal(c.Name)
+	}
+	return json.Marshal(c.Position)
+}
+
+func (c SnapshotColumn) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return strconv.Itoa(c.Position)
+}
+
+// SnapshotError locates a problem within a snapshot file.
+type SnapshotError struct {
+	Line   int
+	Column string
+	Field  string
+	Err    error
+}
+
+func (e *SnapshotError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %s (%s): %s", e.Line, e.Column, e.Field, e.Err)
+}
+
 type Snapshot []SnapshotLine
 
 type SnapshotLine struct {
@@ -18,32 +104,77 @@ type SnapshotLine struct {
 	AccountName     string
 }
 
+// NewSnapshot loads a whole snapshot of EOS balances in memory. Use
+// `NewSnapshotReader` to go through large snapshots line by line.
 func NewSnapshot(content []byte) (out Snapshot, err error) {
-	reader := csv.NewReader(bytes.NewBuffer(content))
-	allRecords, err := reader.ReadAll()
+	reader, err := NewSnapshotReader(bytes.NewBuffer(content), nil, eos.EOSSymbol)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	for _, el := range allRecords {
-		if len(el) != 4 {
-			return nil, fmt.Errorf("should have 4 elements per line")
+	for {
+		line, err := reader.Next()
+		if err == io.EOF {
+			return out, nil
 		}
-
-		newAsset, err := eos.NewEOSAssetFromString(el[3])
 		if err != nil {
 			return out, err
 		}
 
-		pubKey, err := ecc.NewPublicKey(el[2])
-		if err != nil {
-			return out, err
-		}
+		out = append(out, line)
+	}
+}
+
+// SnapshotReader streams `SnapshotLine`s out of a snapshot file,
+// without holding the whole file in memory.
+type SnapshotReader struct {
+	records *snapshotRecords
+	symbol  eos.Symbol
+}
 
-		out = append(out, SnapshotLine{el[0], pubKey, newAsset, el[1]})
+// NewSnapshotReader reads a snapshot laid out as `format` describes,
+// or as historical 4-column CSV when `format` is nil. Balances are
+// read in `symbol`.
+func NewSnapshotReader(r io.Reader, format *SnapshotFormat, symbol eos.Symbol) (*SnapshotReader, error) {
+	records, err := newSnapshotRecords(r, format,
+		[]string{SnapshotEthereumAddress, SnapshotAccountName, SnapshotPublicKey, SnapshotBalance},
+		[]string{SnapshotEthereumAddress, SnapshotPublicKey, SnapshotBalance},
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	return
+	return &SnapshotReader{records: records, symbol: symbol}, nil
+}
+
+// Next returns the next line of the snapshot, or `io.EOF` when there
+// are no more lines.
+func (r *SnapshotReader) Next() (out SnapshotLine, err error) {
+	if err = r.records.next(); err != nil {
+		return
+	}
+
+	newAsset, err := NewAssetFromString(r.records.field(SnapshotBalance), r.symbol)
+	if err != nil {
+		return out, r.records.fieldError(SnapshotBalance, err)
+	}
+
+	pubKey, err := ecc.NewPublicKey(r.records.field(SnapshotPublicKey))
+	if err != nil {
+		return out, r.records.fieldError(SnapshotPublicKey, err)
+	}
+
+	return SnapshotLine{
+		EthereumAddress: r.records.field(SnapshotEthereumAddress),
+		EOSPublicKey:    pubKey,
+		Balance:         newAsset,
+		AccountName:     r.records.field(SnapshotAccountName),
+	}, nil
+}
+
+// Line returns the line number of the last line returned by `Next`.
+func (r *SnapshotReader) Line() int {
+	return r.records.line
 }
 
 type UnregdSnapshot []UnregdSnapshotLine
@@ -54,25 +185,316 @@ type UnregdSnapshotLine struct {
 	Balance         eos.Asset
 }
 
+// NewUnregdSnapshot loads a whole unregistered snapshot of EOS
+// balances in memory. Use `NewUnregdSnapshotReader` to go through
+// large snapshots line by line.
 func NewUnregdSnapshot(content []byte) (out UnregdSnapshot, err error) {
-	reader := csv.NewReader(bytes.NewBuffer(content))
-	allRecords, err := reader.ReadAll()
+	reader, err := NewUnregdSnapshotReader(bytes.NewBuffer(content), nil, eos.EOSSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := reader.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+
+		out = append(out, line)
+	}
+}
+
+// UnregdSnapshotReader streams `UnregdSnapshotLine`s out of a
+// snapshot file, without holding the whole file in memory.
+type UnregdSnapshotReader struct {
+	records *snapshotRecords
+	symbol  eos.Symbol
+}
+
+// NewUnregdSnapshotReader reads an unregistered snapshot laid out as
+// `format` describes, or as historical 3-column CSV when `format` is
+// nil. Balances are read in `symbol`.
+func NewUnregdSnapshotReader(r io.Reader, format *SnapshotFormat, symbol eos.Symbol) (*UnregdSnapshotReader, error) {
+	records, err := newSnapshotRecords(r, format,
+		[]string{SnapshotEthereumAddress, SnapshotAccountName, SnapshotBalance},
+		[]string{SnapshotEthereumAddress, SnapshotBalance},
+	)
 	if err != nil {
+		return nil, err
+	}
+
+	return &UnregdSnapshotReader{records: records, symbol: symbol}, nil
+}
+
+// Next returns the next line of the unregistered snapshot, or
+// `io.EOF` when there are no more lines.
+func (r *UnregdSnapshotReader) Next() (out UnregdSnapshotLine, err error) {
+	if err = r.records.next(); err != nil {
 		return
 	}
 
-	for _, el := range allRecords {
-		if len(el) != 3 {
-			return nil, fmt.Errorf("should have 2 elements per line")
+	newAsset, err := NewAssetFromString(r.records.field(SnapshotBalance), r.symbol)
+	if err != nil {
+		return out, r.records.fieldError(SnapshotBalance, err)
+	}
+
+	return UnregdSnapshotLine{
+		EthereumAddress: r.records.field(SnapshotEthereumAddress),
+		AccountName:     r.records.field(SnapshotAccountName),
+		Balance:         newAsset,
+	}, nil
+}
+
+// Line returns the line number of the last line returned by `Next`.
+func (r *UnregdSnapshotReader) Line() int {
+	return r.records.line
+}
+
+// snapshotRecords reads the raw fields of each line of a snapshot,
+// whatever its format.
+type snapshotRecords struct {
+	format   SnapshotFormat
+	fields   []string
+	required []string
+
+	csv   *csv.Reader
+	jsonl *bufio.Scanner
+
+	line      int
+	positions map[string]int // field name -> 0-based column, for `csv` and `tsv`
+	values    map[string]string
+	fatal     error
+}
+
+func newSnapshotRecords(r io.Reader, format *SnapshotFormat, fields, required []string) (*snapshotRecords, error) {
+	out := &snapshotRecords{
+		fields:   fields,
+		required: required,
+		values:   map[string]string{},
+	}
+	if format != nil {
+		out.format = *format
+	}
+
+	for field := range out.format.Columns {
+		if !stringIn(field, fields) {
+			return nil, fmt.Errorf("unknown snapshot field %q in columns, use one of %q", field, fields)
+		}
+	}
+
+	switch out.format.Type {
+	case "", "csv", "tsv":
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+		reader.FieldsPerRecord = -1
+		if out.format.Type == "tsv" {
+			reader.Comma = '\t'
+			reader.LazyQuotes = true
+		}
+		out.csv = reader
+	case "jsonl":
+		if out.format.Header {
+			return nil, fmt.Errorf("jsonl snapshots have no header line")
+		}
+		for field, col := range out.format.Columns {
+			if col.Name == "" {
+				return nil, fmt.Errorf("column for %q: jsonl snapshots reference columns by key, not position", field)
+			}
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		out.jsonl = scanner
+	default:
+		return nil, fmt.Errorf("unknown snapshot type %q, use one of %q", out.format.Type, []string{"csv", "tsv", "jsonl"})
+	}
+
+	if out.csv != nil && !out.format.Header {
+		if err := out.mapPositions(nil); err != nil {
+			return nil, err
 		}
+	}
+
+	return out, nil
+}
+
+func (r *snapshotRecords) next() error {
+	if r.jsonl != nil {
+		return r.nextJSONLine()
+	}
+
+	if r.fatal != nil {
+		return r.fatal
+	}
 
-		newAsset, err := eos.NewEOSAssetFromString(el[2])
+	if r.format.Header && r.positions == nil {
+		header, err := r.csv.Read()
+		r.line++
 		if err != nil {
-			return out, err
+			r.fatal = r.readError(err)
+			return r.fatal
 		}
 
-		out = append(out, UnregdSnapshotLine{el[0], el[1], newAsset})
+		if err := r.mapPositions(header); err != nil {
+			r.fatal = &SnapshotError{Line: r.line, Err: err}
+			return r.fatal
+		}
 	}
 
-	return
+	record, err := r.csv.Read()
+	r.line++
+	if err != nil {
+		return r.readError(err)
+	}
+
+	if r.format.Columns == nil && !r.format.Header {
+		if len(record) != len(r.fields) {
+			return &SnapshotError{Line: r.line, Err: fmt.Errorf("should have %d columns (%s), got %d", len(r.fields), strings.Join(r.fields, ", "), len(record))}
+		}
+	}
+
+	for _, field := range r.fields {
+		pos, ok := r.positions[field]
+		if !ok {
+			r.values[field] = ""
+			continue
+		}
+		if pos >= len(record) {
+			return &SnapshotError{Line: r.line, Err: fmt.Errorf("column %d (%s) missing, got only %d columns", pos+1, field, len(record))}
+		}
+		r.values[field] = record[pos]
+	}
+
+	return nil
+}
+
+func (r *snapshotRecords) nextJSONLine() error {
+	for {
+		if !r.jsonl.Scan() {
+			if err := r.jsonl.Err(); err != nil {
+				return &SnapshotError{Line: r.line + 1, Err: err}
+			}
+			return io.EOF
+		}
+		r.line++
+
+		if len(bytes.TrimSpace(r.jsonl.Bytes())) != 0 {
+			break
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(r.jsonl.Bytes()))
+	decoder.UseNumber()
+
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return &SnapshotError{Line: r.line, Err: fmt.Errorf("invalid json: %s", err)}
+	}
+
+	for _, field := range r.fields {
+		key := field
+		if col, ok := r.format.Columns[field]; ok {
+			key = col.Name
+		}
+
+		val, found := obj[key]
+		if !found || val == nil {
+			if stringIn(field, r.required) {
+				return &SnapshotError{Line: r.line, Err: fmt.Errorf("key %q (%s) missing", key, field)}
+			}
+			r.values[field] = ""
+			continue
+		}
+
+		r.values[field] = fmt.Sprintf("%v", val)
+	}
+
+	return nil
+}
+
+// mapPositions resolves the column of each field, from the `columns`
+// mapping, the `header` or the default column order.
+func (r *snapshotRecords) mapPositions(header []string) error {
+	positions := map[string]int{}
+
+	switch {
+	case r.format.Columns != nil:
+		for field, col := range r.format.Columns {
+			if col.Name == "" {
+				positions[field] = col.Position - 1
+				continue
+			}
+
+			if header == nil {
+				return fmt.Errorf("column for %q referenced by name %q, but the snapshot has no header", field, col.Name)
+			}
+
+			idx := stringIndex(col.Name, header)
+			if idx == -1 {
+				return fmt.Errorf("column %q (for %s) not found in header %q", col.Name, field, header)
+			}
+			positions[field] = idx
+		}
+	case header != nil:
+		for _, field := range r.fields {
+			if idx := stringIndex(field, header); idx != -1 {
+				positions[field] = idx
+			}
+		}
+	default:
+		for idx, field := range r.fields {
+			positions[field] = idx
+		}
+	}
+
+	for _, field := range r.required {
+		if _, found := positions[field]; !found {
+			return fmt.Errorf("no column for required field %q", field)
+		}
+	}
+
+	r.positions = positions
+	return nil
+}
+
+func (r *snapshotRecords) field(name string) string {
+	return r.values[name]
+}
+
+func (r *snapshotRecords) fieldError(field string, err error) error {
+	column := field
+	if r.jsonl == nil {
+		column = strconv.Itoa(r.positions[field] + 1)
+	} else if col, ok := r.format.Columns[field]; ok {
+		column = col.Name
+	}
+
+	return &SnapshotError{Line: r.line, Column: column, Field: field, Err: err}
+}
+
+func (r *snapshotRecords) readError(err error) error {
+	if err == io.EOF {
+		return err
+	}
+
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return &SnapshotError{Line: parseErr.Line, Err: parseErr.Err}
+	}
+
+	return &SnapshotError{Line: r.line, Err: err}
+}
+
+func stringIndex(needle string, haystack []string) int {
+	for idx, el := range haystack {
+		if strings.TrimSpace(el) == needle {
+			return idx
+		}
+	}
+	return -1
+}
+
+func stringIn(needle string, haystack []string) bool {
+	return stringIndex(needle, haystack) != -1
 }
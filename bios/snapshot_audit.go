@@ -0,0 +1,269 @@
+package bios
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// SnapshotAudit summarizes a snapshot, and flags the lines that need
+// attention before a launch.
+type SnapshotAudit struct {
+	Rows        int
+	TotalSupply eos.Asset
+	Smallest    eos.Asset
+	Largest     eos.Asset
+
+	Distribution []*BalanceBucket
+
+	DuplicateAccountNames      map[string][]int // value -> line numbers
+	DuplicateEthereumAddresses map[string][]int
+	DuplicatePublicKeys        map[string][]int
+
+	InvalidAccountNames []*SnapshotError
+	InvalidRows         []*SnapshotError
+
+	// NoDistribution are the lines with a balance too small to get
+	// anything out of `snapshot.create_accounts`.
+	NoDistribution       []int
+	NoDistributionSupply eos.Asset
+
+	// MinimumStakeOnly are the lines with a balance too small to get
+	// more than the minimum CPU and NET stakes.
+	MinimumStakeOnly []int
+
+	// TruncatedRows are the rows past `TESTNET_TRUNCATE_SNAPSHOT`.
+	TruncatedRows   int
+	TruncatedSupply eos.Asset
+
+	policy *StakePolicy
+
+	seenAccountNames      map[string]int
+	seenEthereumAddresses map[string]int
+	seenPublicKeys        map[string]int
+}
+
+// BalanceBucket counts the balances between `From` (inclusive) and
+// `To` (exclusive). A zero `To` means unbounded.
+type BalanceBucket struct {
+	From  eos.Asset
+	To    eos.Asset
+	Count int
+	Total eos.Asset
+}
+
+// AuditSnapshot goes through all the lines of `reader`. Lines that
+// can't be decoded are reported in `InvalidRows`, only problems with
+// the file itself are returned as errors. Balances are checked
+// against the thresholds of `policy`, and a non-zero `truncate`
+// accounts for the rows that `TESTNET_TRUNCATE_SNAPSHOT` would skip.
+func AuditSnapshot(reader *SnapshotReader, policy *StakePolicy, truncate int) (*SnapshotAudit, error) {
+	zero := eos.Asset{Symbol: reader.symbol}
+	if policy == nil {
+		defaultPolicy := DefaultStakePolicy(reader.symbol)
+		policy = &defaultPolicy
+	}
+
+	audit := &SnapshotAudit{
+		policy:                     policy,
+		TotalSupply:                zero,
+		NoDistributionSupply:       zero,
+		TruncatedSupply:            zero,
+		DuplicateAccountNames:      map[string][]int{},
+		DuplicateEthereumAddresses: map[string][]int{},
+		DuplicatePublicKeys:        map[string][]int{},
+		seenAccountNames:           map[string]int{},
+		seenEthereumAddresses:      map[string]int{},
+		seenPublicKeys:             map[string]int{},
+	}
+
+	from := zero
+	for to := int64(10000); to <= 10000000000; to *= 10 { // 1.0 to 1M, in decades
+		bucket := &BalanceBucket{From: from, To: rescaleAsset(eos.NewEOSAsset(to), reader.symbol), Total: zero}
+		audit.Distribution = append(audit.Distribution, bucket)
+		from = bucket.To
+	}
+	audit.Distribution = append(audit.Distribution, &BalanceBucket{From: from, Total: zero})
+
+	for {
+		hodler, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			lineErr, ok := err.(*SnapshotError)
+			if !ok || reader.records.fatal != nil {
+				return nil, err
+			}
+
+			audit.InvalidRows = append(audit.InvalidRows, lineErr)
+			continue
+		}
+
+		audit.add(reader.Line(), hodler, truncate)
+	}
+
+	return audit, nil
+}
+
+func (a *SnapshotAudit) add(line int, hodler SnapshotLine, truncate int) {
+	balance := hodler.Balance
+
+	a.Rows++
+	a.TotalSupply = a.TotalSupply.Add(balance)
+	if a.Rows == 1 || balance.Amount < a.Smallest.Amount {
+		a.Smallest = balance
+	}
+	if a.Rows == 1 || balance.Amount > a.Largest.Amount {
+		a.Largest = balance
+	}
+
+	for _, bucket := range a.Distribution {
+		if balance.Amount >= bucket.From.Amount && (bucket.To.Amount == 0 || balance.Amount < bucket.To.Amount) {
+			bucket.Count++
+			bucket.Total = bucket.Total.Add(balance)
+			break
+		}
+	}
+
+	trackDuplicate(a.seenAccountNames, a.DuplicateAccountNames, hodler.AccountName, line)
+	trackDuplicate(a.seenEthereumAddresses, a.DuplicateEthereumAddresses, hodler.EthereumAddress, line)
+	trackDuplicate(a.seenPublicKeys, a.DuplicatePublicKeys, hodler.EOSPublicKey.String(), line)
+
+	if err := ValidateAccountName(hodler.AccountName); err != nil {
+		a.InvalidAccountNames = append(a.InvalidAccountNames, &SnapshotError{Line: line, Column: SnapshotAccountName, Field: SnapshotAccountName, Err: err})
+	}
+
+	if balance.Amount < a.policy.MinimumBalance().Amount {
+		if a.policy.SmallBalances == SmallBalancesNone {
+			a.NoDistribution = append(a.NoDistribution, line)
+			a.NoDistributionSupply = a.NoDistributionSupply.Add(balance)
+		}
+	} else if balance.Amount <= a.policy.MinimumStakeOnlyBalance().Amount {
+		a.MinimumStakeOnly = append(a.MinimumStakeOnly, line)
+	}
+
+	if truncate != 0 && a.Rows > truncate {
+		a.TruncatedRows++
+		a.TruncatedSupply = a.TruncatedSupply.Add(balance)
+	}
+}
+
+func trackDuplicate(seen map[string]int, duplicates map[string][]int, value string, line int) {
+	if value == "" {
+		return
+	}
+
+	firstLine, found := seen[value]
+	if !found {
+		seen[value] = line
+		return
+	}
+
+	if len(duplicates[value]) == 0 {
+		duplicates[value] = []int{firstLine}
+	}
+	duplicates[value] = append(duplicates[value], line)
+}
+
+// IssueGap explains the difference between what the boot sequence
+// issues and the snapshot total. `unregistered` is the total of the
+// unregistered snapshot, when known.
+func (a *SnapshotAudit) IssueGap(issued eos.Asset, unregistered *eos.Asset) (gap eos.Asset, explanations []string) {
+	gap = issued.Sub(a.TotalSupply)
+
+	if a.NoDistributionSupply.Amount != 0 {
+		explanations = append(explanations, fmt.Sprintf("%s in %d rows under %s is not distributed, and stays with `eosio`", a.NoDistributionSupply, len(a.NoDistribution), a.policy.MinimumBalance()))
+	}
+
+	if a.TruncatedRows != 0 {
+		explanations = append(explanations, fmt.Sprintf("%s in %d rows past TESTNET_TRUNCATE_SNAPSHOT is not distributed, and stays with `eosio`", a.TruncatedSupply, a.TruncatedRows))
+	}
+
+	unexplained := gap
+	if unregistered != nil {
+		explanations = append(explanations, fmt.Sprintf("%s goes to `eosio.unregd` for the unregistered snapshot", *unregistered))
+		unexplained = unexplained.Sub(*unregistered)
+	}
+
+	switch {
+	case unexplained.Amount > 0:
+		explanations = append(explanations, fmt.Sprintf("%s issued beyond the snapshot balances is left with `eosio` (RAM gifts, reserves, etc..)", unexplained))
+	case unexplained.Amount < 0:
+		explanations = append(explanations, fmt.Sprintf("the boot sequence is SHORT of %s to distribute all balances, `snapshot.create_accounts` will fail", eos.Asset{Amount: -unexplained.Amount, Symbol: unexplained.Symbol}))
+	}
+
+	return
+}
+
+// WriteReport prints the audit in a human-readable form.
+func (a *SnapshotAudit) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "Rows: %d\n", a.Rows)
+	fmt.Fprintf(w, "Total supply: %s\n", a.TotalSupply)
+	if a.Rows != 0 {
+		fmt.Fprintf(w, "Smallest balance: %s\n", a.Smallest)
+		fmt.Fprintf(w, "Largest balance: %s\n", a.Largest)
+		fmt.Fprintf(w, "Average balance: %s\n", eos.Asset{Amount: a.TotalSupply.Amount / eos.Int64(a.Rows), Symbol: a.TotalSupply.Symbol})
+	}
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "Balance distribution:")
+	for _, bucket := range a.Distribution {
+		if bucket.Count == 0 {
+			continue
+		}
+		to := "and up"
+		if bucket.To.Amount != 0 {
+			to = "to " + bucket.To.String()
+		}
+		fmt.Fprintf(w, "- %s %s: %d rows, %s\n", bucket.From, to, bucket.Count, bucket.Total)
+	}
+	fmt.Fprintln(w, "")
+
+	writeDuplicates(w, "account names", a.DuplicateAccountNames)
+	writeDuplicates(w, "Ethereum addresses", a.DuplicateEthereumAddresses)
+	writeDuplicates(w, "public keys", a.DuplicatePublicKeys)
+
+	fmt.Fprintf(w, "Invalid account names: %d\n", len(a.InvalidAccountNames))
+	for _, err := range a.InvalidAccountNames {
+		fmt.Fprintf(w, "- line %d: %s\n", err.Line, err.Err)
+	}
+
+	fmt.Fprintf(w, "Invalid rows: %d\n", len(a.InvalidRows))
+	for _, err := range a.InvalidRows {
+		fmt.Fprintf(w, "- %s\n", err)
+	}
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintf(w, "Rows under %s, getting nothing: %d %s\n", a.policy.MinimumBalance(), len(a.NoDistribution), lineNumbers(a.NoDistribution))
+	fmt.Fprintf(w, "Rows up to %s, getting only the minimum stakes: %d %s\n", a.policy.MinimumStakeOnlyBalance(), len(a.MinimumStakeOnly), lineNumbers(a.MinimumStakeOnly))
+}
+
+func writeDuplicates(w io.Writer, label string, duplicates map[string][]int) {
+	fmt.Fprintf(w, "Duplicate %s: %d\n", label, len(duplicates))
+
+	var values []string
+	for value := range duplicates {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	for _, value := range values {
+		fmt.Fprintf(w, "- %s on lines %s\n", value, lineNumbers(duplicates[value]))
+	}
+}
+
+func lineNumbers(lines []int) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	const maxLines = 20
+	out := fmt.Sprintf("%v", lines)
+	if len(lines) > maxLines {
+		out = fmt.Sprintf("%v and %d more", lines[:maxLines], len(lines)-maxLines)
+	}
+	return out
+}
@@ -0,0 +1,229 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotReader(t *testing.T) {
+	content := `0x00000000000000000000000000000000000000b1,b1b1b1b1b1b1,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,100000000.0000
+0xf23221e40732b34d84db1d30da95367a160da090,gi2dmnzxgege,EOS5q6CLgoio5TkSNCPzwGqJNouvrfCt38iKnZy5rDcPixTGxq6CD,10300399.6501
+`
+
+	reader, err := NewSnapshotReader(bytes.NewBufferString(content), nil, eos.EOSSymbol)
+	require.NoError(t, err)
+
+	line, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "0x00000000000000000000000000000000000000b1", line.EthereumAddress)
+	assert.Equal(t, "b1b1b1b1b1b1", line.AccountName)
+	assert.Equal(t, "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", line.EOSPublicKey.String())
+	assert.Equal(t, eos.NewEOSAsset(1000000000000), line.Balance)
+
+	line, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "gi2dmnzxgege", line.AccountName)
+	assert.Equal(t, eos.NewEOSAsset(103003996501), line.Balance)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	snapshot, err := NewSnapshot([]byte(content))
+	require.NoError(t, err)
+	assert.Len(t, snapshot, 2)
+}
+
+func TestSnapshotReaderFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{
+			name:    "csv with header",
+			format:  `{"header": true}`,
+			content: "balance,public_key,account_name,ethereum_address,comment\n10.0000,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,b1b1b1b1b1b1,0xb1,first\n",
+		},
+		{
+			name:    "csv with column positions",
+			format:  `{"columns": {"ethereum_address": 4, "account_name": 1, "public_key": 3, "balance": 2}}`,
+			content: "b1b1b1b1b1b1,10.0000,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,0xb1\n",
+		},
+		{
+			name:    "tsv with header and column names",
+			format:  `{"type": "tsv", "header": true, "columns": {"ethereum_address": "eth", "account_name": "name", "public_key": "key", "balance": "amount"}}`,
+			content: "eth\tname\tkey\tamount\n0xb1\tb1b1b1b1b1b1\tEOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ\t10.0000\n",
+		},
+		{
+			name:    "jsonl",
+			format:  `{"type": "jsonl", "columns": {"balance": "amount"}}`,
+			content: `{"ethereum_address": "0xb1", "account_name": "b1b1b1b1b1b1", "public_key": "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", "amount": 10.0000}` + "\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var format *SnapshotFormat
+			require.NoError(t, json.Unmarshal([]byte(test.format), &format))
+
+			reader, err := NewSnapshotReader(bytes.NewBufferString(test.content), format, eos.EOSSymbol)
+			require.NoError(t, err)
+
+			line, err := reader.Next()
+			require.NoError(t, err)
+			assert.Equal(t, SnapshotLine{
+				EthereumAddress: "0xb1",
+				EOSPublicKey:    ecc.MustNewPublicKey("EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ"),
+				Balance:         eos.NewEOSAsset(100000),
+				AccountName:     "b1b1b1b1b1b1",
+			}, line)
+
+			_, err = reader.Next()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestSnapshotReaderTestData(t *testing.T) {
+	content, err := ioutil.ReadFile("test-data/snapshot.csv")
+	require.NoError(t, err)
+
+	_, err = NewSnapshot(content)
+	assert.EqualError(t, err, "line 1: should have 4 columns (ethereum_address, account_name, public_key, balance), got 3")
+
+	reader, err := NewSnapshotReader(bytes.NewBuffer(content), &SnapshotFormat{
+		Columns: map[string]SnapshotColumn{
+			SnapshotEthereumAddress: {Position: 1},
+			SnapshotPublicKey:       {Position: 2},
+			SnapshotBalance:         {Position: 3},
+		},
+	}, eos.EOSSymbol)
+	require.NoError(t, err)
+
+	count := 0
+	for {
+		line, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "", line.AccountName)
+		count++
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestSnapshotReaderErrors(t *testing.T) {
+	tests := []struct {
+		format  *SnapshotFormat
+		content string
+		err     string
+	}{
+		{
+			content: "0xb1,b1b1b1b1b1b1,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,10.0000\n0xb2,b2b2b2b2b2b2,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,ten\n",
+			err:     `line 2, column 4 (balance): strconv.ParseInt: parsing "ten": invalid syntax`,
+		},
+		{
+			format:  &SnapshotFormat{Header: true},
+			content: "ethereum_address,account_name,public_key,balance\n0xb1,b1b1b1b1b1b1,EOS5bad,10.0000\n",
+			err:     `line 2, column 3 (public_key): invalid format`,
+		},
+		{
+			format:  &SnapshotFormat{Header: true},
+			content: "ethereum_address,account_name,balance\n",
+			err:     `line 1: no column for required field "public_key"`,
+		},
+		{
+			format:  &SnapshotFormat{Type: "jsonl"},
+			content: `{"ethereum_address": "0xb1", "balance": "1.0000"}`,
+			err:     `line 1: key "public_key" (public_key) missing`,
+		},
+	}
+
+	for idx, test := range tests {
+		reader, err := NewSnapshotReader(bytes.NewBufferString(test.content), test.format, eos.EOSSymbol)
+		require.NoError(t, err)
+
+		for {
+			_, err = reader.Next()
+			if err != nil {
+				break
+			}
+		}
+		assert.EqualError(t, err, test.err, fmt.Sprintf("idx=%d", idx))
+	}
+}
+
+func TestUnregdSnapshotReader(t *testing.T) {
+	_, err := NewUnregdSnapshot([]byte("0xb1,10.0000\n"))
+	assert.EqualError(t, err, "line 1: should have 3 columns (ethereum_address, account_name, balance), got 2")
+
+	snapshot, err := NewUnregdSnapshot([]byte("0xb1,b1b1b1b1b1b1,10.0000\n"))
+	require.NoError(t, err)
+	assert.Equal(t, UnregdSnapshot{{"0xb1", "b1b1b1b1b1b1", eos.NewEOSAsset(100000)}}, snapshot)
+}
+
+func TestAuditSnapshot(t *testing.T) {
+	content := `0xaaaaaa,alice,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,0.1000
+0xbbbbbb,Bob,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,5.0000
+0xaaaaaa,alice,EOS5q6CLgoio5TkSNCPzwGqJNouvrfCt38iKnZy5rDcPixTGxq6CD,12345.0000
+0xcccccc,carol,EOS5q6CLgoio5TkSNCPzwGqJNouvrfCt38iKnZy5rDcPixTGxq6CD,abc
+`
+
+	reader, err := NewSnapshotReader(bytes.NewBufferString(content), nil, eos.EOSSymbol)
+	require.NoError(t, err)
+
+	audit, err := AuditSnapshot(reader, nil, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, audit.Rows)
+	assert.Equal(t, eos.NewEOSAsset(123501000), audit.TotalSupply)
+	assert.Equal(t, map[string][]int{"alice": {1, 3}}, audit.DuplicateAccountNames)
+	assert.Equal(t, map[string][]int{"0xaaaaaa": {1, 3}}, audit.DuplicateEthereumAddresses)
+	assert.Equal(t, map[string][]int{"EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ": {1, 2}}, audit.DuplicatePublicKeys)
+	require.Len(t, audit.InvalidAccountNames, 1)
+	assert.Equal(t, 2, audit.InvalidAccountNames[0].Line)
+	require.Len(t, audit.InvalidRows, 1)
+	assert.Equal(t, 4, audit.InvalidRows[0].Line)
+	assert.Equal(t, []int{1}, audit.NoDistribution)
+	assert.Equal(t, []int{2}, audit.MinimumStakeOnly)
+	assert.Equal(t, 1, audit.TruncatedRows)
+
+	unregistered := eos.NewEOSAsset(10000)
+	gap, explanations := audit.IssueGap(eos.NewEOSAsset(123601000), &unregistered)
+	assert.Equal(t, eos.NewEOSAsset(100000), gap)
+	assert.Len(t, explanations, 4)
+}
+
+func TestStreamChunks(t *testing.T) {
+	a1 := &eos.Action{Name: "one"}
+	a2 := &eos.Action{Name: "two"}
+	a3 := &eos.Action{Name: "three"}
+
+	acts := []*eos.Action{nil, a1, a2, nil, nil, a3}
+
+	var chunks [][]*eos.Action
+	err := (&BIOS{}).streamChunks(&staticOp{acts}, func(chunk []*eos.Action) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ChunkifyActions(acts), chunks)
+	assert.Equal(t, [][]*eos.Action{{a1, a2}, {a3}}, chunks)
+}
+
+type staticOp struct {
+	acts []*eos.Action
+}
+
+func (op *staticOp) Actions(b *BIOS) ([]*eos.Action, error) { return op.acts, nil }
@@ -0,0 +1,330 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StepLoop is the `for_each:` of a step, repeating it for each of its
+// `items`, or for each row of the CSV `content`, read as maps keyed by
+// the header row. The item is the variable `as`, `item` by default.
+type StepLoop struct {
+	Items   []interface{} `json:"items"`
+	Content string        `json:"content"`
+	As      string        `json:"as"`
+}
+
+// StepCondition is the `when:` of a step, which is only kept when the
+// variable `var` is true (or `equals` the given value) and the profile
+// `profile` is active. `not` negates the condition. `when:` can also
+// be a plain boolean, like `when: ${testnet}`.
+type StepCondition struct {
+	Var     string      `json:"var"`
+	Equals  interface{} `json:"equals"`
+	Profile string      `json:"profile"`
+	Not     bool        `json:"not"`
+}
+
+type stepExpander struct {
+	vars     *varResolver
+	contents []*ContentRef
+	declared map[string]bool
+	active   map[string]bool
+}
+
+func newStepExpander(vars *varResolver, doc map[string]interface{}, profiles []string) (*stepExpander, error) {
+	e := &stepExpander{
+		vars:     vars,
+		declared: map[string]bool{},
+		active:   map[string]bool{},
+	}
+
+	if err := remarshal(doc["contents"], &e.contents); err != nil {
+		return nil, fmt.Errorf("contents: %s", err)
+	}
+
+	var declared []string
+	if err := remarshal(doc["profiles"], &declared); err != nil {
+		return nil, fmt.Errorf("profiles: %s", err)
+	}
+	for _, profile := range declared {
+		e.declared[profile] = true
+	}
+	for _, profile := range profiles {
+		if !e.declared[profile] {
+			return nil, fmt.Errorf("--profile %s: no such profile in profiles", profile)
+		}
+		e.active[profile] = true
+	}
+
+	return e, nil
+}
+
+// expand repeats the steps with a `for_each:` and drops the ones whose
+// `when:` doesn't hold, resolving the variables of each step. Repeated
+// steps get a `(n/total)` suffix to their label, and `.n` to their
+// `id`, n being the position of the item, so they are the same from
+// one run to the next.
+func (e *stepExpander) expand(rawSteps interface{}) ([]interface{}, error) {
+	if rawSteps == nil {
+		return nil, nil
+	}
+	steps, ok := rawSteps.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a list of steps")
+	}
+
+	var out []interface{}
+	for idx, step := range steps {
+		fields, ok := step.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("step #%d: must be a map", idx+1)
+		}
+
+		expanded, err := e.expandStep(fields)
+		if err != nil {
+			if id, _ := fields["id"].(string); id != "" {
+				return nil, fmt.Errorf("step #%d (%s): %s", idx+1, id, err)
+			}
+			return nil, fmt.Errorf("step #%d: %s", idx+1, err)
+		}
+		out = append(out, expanded...)
+	}
+	return out, nil
+}
+
+func (e *stepExpander) expandStep(fields map[string]interface{}) ([]interface{}, error) {
+	rawLoop, found := fields["for_each"]
+	if !found {
+		step, err := e.resolveStep(e.vars, fields)
+		if err != nil || step == nil {
+			return nil, err
+		}
+		return []interface{}{step}, nil
+	}
+
+	resolvedLoop, err := e.vars.resolve(rawLoop)
+	if err != nil {
+		return nil, fmt.Errorf("for_each: %s", err)
+	}
+	var loop *StepLoop
+	if err := remarshal(resolvedLoop, &loop); err != nil {
+		return nil, fmt.Errorf("for_each: %s", err)
+	}
+	if loop == nil {
+		return nil, fmt.Errorf("for_each: items or content missing")
+	}
+
+	items, err := e.loopItems(loop)
+	if err != nil {
+		return nil, fmt.Errorf("for_each: %s", err)
+	}
+
+	as := loop.As
+	if as == "" {
+		as = "item"
+	}
+	if _, found := e.vars.vars[as]; found {
+		return nil, fmt.Errorf("for_each: %q is already a variable, set another `as`", as)
+	}
+
+	var out []interface{}
+	for idx, item := range items {
+		step, err := e.resolveStep(e.vars.with(as, item), fields)
+		if err != nil {
+			return nil, fmt.Errorf("item #%d: %s", idx+1, err)
+		}
+		if step == nil {
+			continue
+		}
+
+		label, _ := step["label"].(string)
+		step["label"] = strings.TrimSpace(fmt.Sprintf("%s (%d/%d)", label, idx+1, len(items)))
+		if id, _ := step["id"].(string); id != "" {
+			step["id"] = fmt.Sprintf("%s.%d", id, idx+1)
+		}
+		out = append(out, step)
+	}
+	return out, nil
+}
+
+// resolveStep returns the step with its variables resolved from
+// `scope`, or nil when its `when:` doesn't hold.
+func (e *stepExpander) resolveStep(scope *varResolver, fields map[string]interface{}) (map[string]interface{}, error) {
+	if rawWhen, found := fields["when"]; found {
+		when, err := scope.resolve(rawWhen)
+		if err != nil {
+			return nil, fmt.Errorf("when: %s", err)
+		}
+
+		holds, err := e.evaluate(scope, when)
+		if err != nil {
+			return nil, fmt.Errorf("when: %s", err)
+		}
+		if !holds {
+			return nil, nil
+		}
+	}
+
+	out := map[string]interface{}{}
+	for key, value := range fields {
+		if key == "for_each" || key == "when" {
+			continue
+		}
+
+		resolved, err := scope.resolve(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err)
+		}
+		out[key] = resolved
+	}
+	return out, nil
+}
+
+func (e *stepExpander) evaluate(scope *varResolver, when interface{}) (bool, error) {
+	switch v := when.(type) {
+	case bool:
+		return v, nil
+	case map[string]interface{}:
+		var cond *StepCondition
+		if err := remarshal(v, &cond); err != nil {
+			return false, err
+		}
+		return cond.holds(scope, e.declared, e.active)
+	default:
+		return false, fmt.Errorf("must be a boolean or a condition, got %v", when)
+	}
+}
+
+func (c *StepCondition) holds(scope *varResolver, declared, active map[string]bool) (bool, error) {
+	if c.Var == "" && c.Profile == "" {
+		return false, fmt.Errorf("set var or profile")
+	}
+
+	out := true
+	if c.Var != "" {
+		value, err := scope.lookup(c.Var)
+		if err != nil {
+			return false, err
+		}
+
+		if c.Equals != nil {
+			out = fmt.Sprint(value) == fmt.Sprint(c.Equals)
+		} else {
+			out = truthy(value)
+		}
+	}
+
+	if c.Profile != "" {
+		if !declared[c.Profile] {
+			return false, fmt.Errorf("no such profile %q in profiles", c.Profile)
+		}
+		out = out && active[c.Profile]
+	}
+
+	if c.Not {
+		return !out, nil
+	}
+	return out, nil
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "false" && v != "0"
+	case json.Number:
+		return v.String() != "0"
+	case []interface{}:
+		return len(v) != 0
+	case map[string]interface{}:
+		return len(v) != 0
+	default:
+		return true
+	}
+}
+
+func (e *stepExpander) loopItems(loop *StepLoop) ([]interface{}, error) {
+	if loop.Content != "" {
+		if loop.Items != nil {
+			return nil, fmt.Errorf("set items or content, not both")
+		}
+		return e.contentRows(loop.Content)
+	}
+
+	if loop.Items == nil {
+		return nil, fmt.Errorf("items or content missing")
+	}
+	return loop.Items, nil
+}
+
+// contentRows reads the CSV file of `contents:` named `name`, as maps
+// keyed by its header row. It is read from its URL, as steps are
+// expanded before contents are downloaded.
+func (e *stepExpander) contentRows(name string) ([]interface{}, error) {
+	var ref *ContentRef
+	for _, content := range e.contents {
+		if content.Name == name {
+			ref = content
+		}
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("no content named %q in contents", name)
+	}
+
+	cnt, err := readInclude(ref.URL)
+	if err != nil {
+		return nil, fmt.Errorf("content %q: %s", name, err)
+	}
+	if err := verifyHash(cnt, ref.Hash); err != nil {
+		return nil, fmt.Errorf("content %q: %s", name, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(cnt))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("content %q: header row missing", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("content %q: %s", name, err)
+	}
+
+	var out []interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("content %q: %s", name, err)
+		}
+
+		row := map[string]interface{}{}
+		for idx, column := range header {
+			row[strings.TrimSpace(column)] = record[idx]
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// with returns a resolver of the variables resolved by `r`, plus the
+// variable `name`.
+func (r *varResolver) with(name string, value interface{}) *varResolver {
+	vars := map[string]interface{}{}
+	for key, resolved := range r.resolved {
+		vars[key] = resolved
+	}
+	vars[name] = value
+
+	return &varResolver{vars: vars, resolved: vars, resolving: map[string]bool{}}
+}
@@ -0,0 +1,105 @@
+package bios
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSteps(t *testing.T) {
+	csvFile, err := ioutil.TempFile("", "eos-bios-producers")
+	require.NoError(t, err)
+	defer os.Remove(csvFile.Name())
+	_, err = csvFile.WriteString("account, url\neosbarbados1,https://barbados.example\neoscanadacom,https://canada.example\n")
+	require.NoError(t, err)
+	require.NoError(t, csvFile.Close())
+
+	resolved, err := ResolveBootSeq([]byte(`
+vars:
+  testnet: false
+  contracts: [eosio.msig, eosio.token]
+profiles: [testnet, mainnet]
+contents:
+- {name: producers.csv, url: `+csvFile.Name()+`}
+boot_sequence:
+- op: system.regproducer
+  id: producers
+  label: Register ${producer.account}
+  for_each: {content: producers.csv, as: producer}
+  data: {account: "${producer.account}", url: "${producer.url}"}
+- op: system.setcode
+  id: setcode
+  label: Set code
+  for_each: {items: "${contracts}"}
+  when: {var: item, equals: eosio.token, not: true}
+  data: {account: "${item}"}
+- op: system.resign_accounts
+  when: {profile: testnet, not: true}
+- op: system.setpriv
+  when: ${testnet}
+`), nil, []string{"testnet"})
+	require.NoError(t, err)
+
+	var out struct {
+		BootSequence []map[string]interface{} `json:"boot_sequence"`
+	}
+	require.NoError(t, json.Unmarshal(resolved, &out))
+
+	assert.Equal(t, []map[string]interface{}{
+		{"op": "system.regproducer", "id": "producers.1", "label": "Register eosbarbados1 (1/2)", "data": map[string]interface{}{"account": "eosbarbados1", "url": "https://barbados.example"}},
+		{"op": "system.regproducer", "id": "producers.2", "label": "Register eoscanadacom (2/2)", "data": map[string]interface{}{"account": "eoscanadacom", "url": "https://canada.example"}},
+		{"op": "system.setcode", "id": "setcode.1", "label": "Set code (1/2)", "data": map[string]interface{}{"account": "eosio.msig"}},
+	}, out.BootSequence)
+}
+
+func TestExpandStepsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		profiles  []string
+		expectErr string
+	}{
+		{
+			name:      "unknown profile flag",
+			yaml:      "profiles: [testnet]\n",
+			profiles:  []string{"mainnet"},
+			expectErr: "--profile mainnet: no such profile in profiles",
+		},
+		{
+			name:      "unknown profile condition",
+			yaml:      "boot_sequence:\n- {op: system.setpriv, when: {profile: testnet}}\n",
+			expectErr: `boot_sequence: step #1: when: no such profile "testnet" in profiles`,
+		},
+		{
+			name:      "bad condition",
+			yaml:      "boot_sequence:\n- {op: system.setpriv, when: yes please}\n",
+			expectErr: "boot_sequence: step #1: when: must be a boolean or a condition, got yes please",
+		},
+		{
+			name:      "shadowed variable",
+			yaml:      "vars: {item: 1}\nboot_sequence:\n- {op: system.setpriv, id: priv, for_each: {items: [a]}}\n",
+			expectErr: `boot_sequence: step #1 (priv): for_each: "item" is already a variable, set another ` + "`as`",
+		},
+		{
+			name:      "missing content",
+			yaml:      "boot_sequence:\n- {op: system.setpriv, for_each: {content: producers.csv}}\n",
+			expectErr: `boot_sequence: step #1: for_each: no content named "producers.csv" in contents`,
+		},
+		{
+			name:      "missing field",
+			yaml:      "boot_sequence:\n- {op: system.setpriv, for_each: {items: [{name: a}]}, data: {account: '${item.account}'}}\n",
+			expectErr: `boot_sequence: step #1: item #1: data: variable "item" has no field "account"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ResolveBootSeq([]byte(test.yaml), nil, test.profiles)
+			assert.EqualError(t, err, test.expectErr)
+		})
+	}
+}
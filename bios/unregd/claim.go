@@ -0,0 +1,112 @@
+package unregd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eoscanada/eos-go/btcsuite/btcd/btcec"
+	"github.com/eoscanada/eos-go/btcsuite/btcutil"
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrNonCanonical is returned by `SignClaim` when the signature is
+// not canonical. The message depends on the TaPoS of the
+// transaction, so signing again over a newer block fixes it.
+var ErrNonCanonical = errors.New("signature is not canonical")
+
+// ParseEthereumPrivateKey reads a secp256k1 private key, either as
+// 64 hex characters (optionally prefixed with `0x`) or in WIF
+// format, like `claim.py` accepts them.
+func ParseEthereumPrivateKey(in string) (*btcec.PrivateKey, error) {
+	in = strings.TrimSpace(in)
+
+	hexKey := strings.TrimPrefix(strings.TrimPrefix(in, "0x"), "0X")
+	if len(hexKey) == 64 {
+		raw, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("decoding hex private key: %s", err)
+		}
+
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), raw)
+		return privKey, nil
+	}
+
+	wif, err := btcutil.DecodeWIF(in)
+	if err != nil {
+		return nil, fmt.Errorf("private key is neither 64 hex characters nor WIF: %s", err)
+	}
+
+	return wif.PrivKey, nil
+}
+
+// EthereumAddress returns the `0x`-prefixed address of `pubKey`, the
+// last 20 bytes of the keccak256 hash of its uncompressed form.
+func EthereumAddress(pubKey *btcec.PublicKey) string {
+	uncompressed := pubKey.SerializeUncompressed()
+	hash := keccak256(uncompressed[1:]) // skip the 0x04 prefix
+	return "0x" + hex.EncodeToString(hash[12:])
+}
+
+// ClaimMessage is the message `regaccount` rebuilds from the TaPoS of
+// the transaction carrying it, and checks the signature against.
+func ClaimMessage(refBlockNum uint16, refBlockPrefix uint32, eosPubKey, account string) string {
+	return fmt.Sprintf("%d,%d,%s,%s", refBlockNum, refBlockPrefix, eosPubKey, account)
+}
+
+// PersonalMessageHash hashes `message` like Ethereum's
+// `personal_sign`, which is what wallets sign and what the contract
+// verifies.
+func PersonalMessageHash(message string) []byte {
+	return keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+}
+
+// SignClaim signs `hash` and returns the 66 bytes `regaccount`
+// expects: the K1 key type (0x00), followed by the compact signature
+// (v, r, s). It returns `ErrNonCanonical` when the node would refuse
+// the signature.
+func SignClaim(privKey *btcec.PrivateKey, hash []byte) ([]byte, error) {
+	compact, err := btcec.SignCompact(btcec.S256(), privKey, hash, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsCanonical(compact) {
+		return nil, ErrNonCanonical
+	}
+
+	return append([]byte{0x00}, compact...), nil
+}
+
+// IsCanonical checks a 65 bytes compact signature the way `fc` does.
+func IsCanonical(compact []byte) bool {
+	return len(compact) == 65 &&
+		compact[1]&0x80 == 0 &&
+		!(compact[1] == 0 && compact[2]&0x80 == 0) &&
+		compact[33]&0x80 == 0 &&
+		!(compact[33] == 0 && compact[34]&0x80 == 0)
+}
+
+// ValidateClaimAccount applies the rules of `regaccount` on the
+// requested account name: exactly 12 characters, from `a-z` and
+// `1-5`.
+func ValidateClaimAccount(account string) error {
+	if len(account) != 12 {
+		return fmt.Errorf("account name %q should have exactly 12 characters", account)
+	}
+
+	for _, c := range account {
+		if !((c >= 'a' && c <= 'z') || (c >= '1' && c <= '5')) {
+			return fmt.Errorf("account name %q has invalid character %q, only a-z and 1-5 are allowed", account, c)
+		}
+	}
+
+	return nil
+}
+
+func keccak256(data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
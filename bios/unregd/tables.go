@@ -0,0 +1,47 @@
+package unregd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// Address is a row of the `addresses` table.
+type Address struct {
+	ID              eos.Uint64 `json:"id"`
+	EthereumAddress string     `json:"ethereum_address"`
+	Balance         eos.Asset  `json:"balance"`
+}
+
+// FetchAddresses pages through the whole `addresses` table of
+// `eosio.unregd`, `pageSize` rows at a time.
+func FetchAddresses(api *eos.API, pageSize uint32) (out []Address, err error) {
+	lowerBound := ""
+	for {
+		resp, err := api.GetTableRows(eos.GetTableRowsRequest{
+			Code:       "eosio.unregd",
+			Scope:      "eosio.unregd",
+			Table:      "addresses",
+			LowerBound: lowerBound,
+			Limit:      pageSize,
+			JSON:       true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get table rows: %s", err)
+		}
+
+		var rows []Address
+		if err := resp.JSONToStructs(&rows); err != nil {
+			return nil, fmt.Errorf("decoding rows: %s", err)
+		}
+
+		out = append(out, rows...)
+
+		if !resp.More || len(rows) == 0 {
+			return out, nil
+		}
+
+		lowerBound = strconv.FormatUint(uint64(rows[len(rows)-1].ID)+1, 10)
+	}
+}
@@ -7,7 +7,7 @@ func NewAdd(ethAccount string, balance eos.Asset) *eos.Action {
 		Account: eos.AccountName("eosio.unregd"),
 		Name:    eos.ActionName("add"),
 		Authorization: []eos.PermissionLevel{
-			{eos.AccountName("eosio.unregd"), eos.PermissionName("active")},
+			{Actor: eos.AccountName("eosio.unregd"), Permission: eos.PermissionName("active")},
 		},
 		ActionData: eos.NewActionData(Add{
 			EthereumAddress: ethAccount,
@@ -21,3 +21,68 @@ type Add struct {
 	EthereumAddress string    `json:"ethereum_account"`
 	Balance         eos.Asset `json:"balance"`
 }
+
+// NewRegAccount claims the balance of the Ethereum address that
+// signed `signature` (see `SignClaim`). The contract doesn't require
+// any authorization, `pusher` only pays for the transaction.
+func NewRegAccount(pusher eos.PermissionLevel, signature []byte, account string, eosPubKey string) *eos.Action {
+	action := &eos.Action{
+		Account:       eos.AccountName("eosio.unregd"),
+		Name:          eos.ActionName("regaccount"),
+		Authorization: []eos.PermissionLevel{pusher},
+		ActionData: eos.NewActionData(RegAccount{
+			Signature: eos.HexBytes(signature),
+			Account:   account,
+			EOSPubKey: eosPubKey,
+		}),
+	}
+	return action
+}
+
+type RegAccount struct {
+	Signature eos.HexBytes `json:"signature"`
+	Account   string       `json:"account"`
+	EOSPubKey string       `json:"eos_pubkey"`
+}
+
+// NewChangeAddress moves an unregistered balance to another Ethereum
+// address.
+func NewChangeAddress(oldAddress, newAddress string) *eos.Action {
+	action := &eos.Action{
+		Account: eos.AccountName("eosio.unregd"),
+		Name:    eos.ActionName("chngaddress"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: eos.AccountName("eosio.unregd"), Permission: eos.PermissionName("active")},
+		},
+		ActionData: eos.NewActionData(ChangeAddress{
+			OldAddress: oldAddress,
+			NewAddress: newAddress,
+		}),
+	}
+	return action
+}
+
+type ChangeAddress struct {
+	OldAddress string `json:"old_address"`
+	NewAddress string `json:"new_address"`
+}
+
+// NewSetMaxEOS sets the most the contract pays for the 8 KiB of RAM
+// of each claimed account.
+func NewSetMaxEOS(maxEOS eos.Asset) *eos.Action {
+	action := &eos.Action{
+		Account: eos.AccountName("eosio.unregd"),
+		Name:    eos.ActionName("setmaxeos"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: eos.AccountName("eosio.unregd"), Permission: eos.PermissionName("active")},
+		},
+		ActionData: eos.NewActionData(SetMaxEOS{
+			MaxEOS: maxEOS,
+		}),
+	}
+	return action
+}
+
+type SetMaxEOS struct {
+	MaxEOS eos.Asset `json:"maxeos"`
+}
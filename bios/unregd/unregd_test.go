@@ -0,0 +1,102 @@
+package unregd
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthereumAddress(t *testing.T) {
+	tests := []struct {
+		privKey string
+		address string
+	}{
+		{"0x0000000000000000000000000000000000000000000000000000000000000001", "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"},
+		{"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"},
+	}
+
+	for _, test := range tests {
+		privKey, err := ParseEthereumPrivateKey(test.privKey)
+		require.NoError(t, err)
+		assert.Equal(t, test.address, EthereumAddress(privKey.PubKey()))
+	}
+
+	_, err := ParseEthereumPrivateKey("0xabc")
+	assert.Error(t, err)
+}
+
+func TestSignClaim(t *testing.T) {
+	privKey, err := ParseEthereumPrivateKey("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+
+	// Known `personal_sign` vector, as produced by web3 and MetaMask
+	hash := PersonalMessageHash("Some data")
+	assert.Equal(t, "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655", hex.EncodeToString(hash))
+
+	compact, err := btcec.SignCompact(btcec.S256(), privKey, hash, false)
+	require.NoError(t, err)
+	assert.Equal(t, "1c"+
+		"b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd"+
+		"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029", hex.EncodeToString(compact))
+
+	// `r` has its high bit set, which nodeos refuses
+	_, err = SignClaim(privKey, hash)
+	assert.Equal(t, ErrNonCanonical, err)
+
+	var signature []byte
+	for refBlockNum := uint16(1); ; refBlockNum++ {
+		hash = PersonalMessageHash(ClaimMessage(refBlockNum, 3214567890, "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", "b1b1b1b1b1b1"))
+		signature, err = SignClaim(privKey, hash)
+		if err != ErrNonCanonical {
+			break
+		}
+	}
+	require.NoError(t, err)
+	require.Len(t, signature, 66)
+	assert.Equal(t, byte(0x00), signature[0])
+
+	pubKey, _, err := btcec.RecoverCompact(btcec.S256(), signature[1:], hash)
+	require.NoError(t, err)
+	assert.Equal(t, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", EthereumAddress(pubKey))
+}
+
+func TestClaimMessage(t *testing.T) {
+	assert.Equal(t,
+		"45123,3214567890,EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ,b1b1b1b1b1b1",
+		ClaimMessage(45123, 3214567890, "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ", "b1b1b1b1b1b1"),
+	)
+}
+
+func TestIsCanonical(t *testing.T) {
+	sig := make([]byte, 65)
+	sig[1], sig[33] = 0x7f, 0x7f
+	assert.True(t, IsCanonical(sig))
+
+	sig[1] = 0x80
+	assert.False(t, IsCanonical(sig))
+
+	sig[1], sig[2] = 0x00, 0x7f
+	assert.False(t, IsCanonical(sig))
+
+	sig[2] = 0x80
+	assert.True(t, IsCanonical(sig))
+}
+
+func TestValidateClaimAccount(t *testing.T) {
+	assert.NoError(t, ValidateClaimAccount("b1b1b1b1b1b1"))
+	assert.Error(t, ValidateClaimAccount("b1b1"))
+	assert.Error(t, ValidateClaimAccount("b1b1b1b1b1b."))
+	assert.Error(t, ValidateClaimAccount("b1b1b1b1b1b9"))
+}
+
+func TestNewRegAccount(t *testing.T) {
+	act := NewRegAccount(eos.PermissionLevel{Actor: "pusher", Permission: "active"}, []byte{0x00, 0x1c}, "b1b1b1b1b1b1", "EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ")
+
+	data, err := eos.MarshalBinary(act.ActionData.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "02001c"+"0c"+hex.EncodeToString([]byte("b1b1b1b1b1b1"))+"35"+hex.EncodeToString([]byte("EOS5cujNHGMYZZ2tgByyNEUaoPLFhZVmGXbZc9BLJeQkKZFqGYEiQ")), hex.EncodeToString(data))
+}
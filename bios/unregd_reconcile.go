@@ -0,0 +1,148 @@
+package bios
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/eoscanada/eos-bios/bios/unregd"
+	"github.com/eoscanada/eos-go"
+)
+
+// UnregdReconciliation compares the `addresses` table of
+// `eosio.unregd`, and its token balance, with the unregistered
+// snapshot it was loaded from.
+type UnregdReconciliation struct {
+	SnapshotRows  int
+	SnapshotTotal eos.Asset
+	TableRows     int
+	TableTotal    eos.Asset
+	TokenBalance  eos.Asset
+
+	// Missing are snapshot lines without a row in the table. They
+	// were either never loaded, or claimed since.
+	Missing []UnregdSnapshotLine
+	// Extra are table rows absent from the snapshot.
+	Extra []unregd.Address
+	// Mismatched are the addresses where the table and the snapshot
+	// disagree on the balance.
+	Mismatched []*UnregdMismatch
+}
+
+// UnregdMismatch is a balance that differs between the snapshot and
+// the table.
+type UnregdMismatch struct {
+	EthereumAddress string
+	Snapshot        eos.Asset
+	Table           eos.Asset
+}
+
+// ReconcileUnregd matches each row of `rows` against the snapshot
+// lines of `reader` by Ethereum address. The addresses are compared
+// case-insensitively, as checksummed addresses are mixed-case.
+func ReconcileUnregd(reader *UnregdSnapshotReader, rows []unregd.Address, tokenBalance eos.Asset) (*UnregdReconciliation, error) {
+	zero := eos.Asset{Symbol: reader.symbol}
+	rec := &UnregdReconciliation{
+		SnapshotTotal: zero,
+		TableTotal:    zero,
+		TokenBalance:  tokenBalance,
+	}
+
+	tableRows := map[string]unregd.Address{}
+	for _, row := range rows {
+		rec.TableRows++
+		rec.TableTotal = rec.TableTotal.Add(row.Balance)
+		tableRows[strings.ToLower(row.EthereumAddress)] = row
+	}
+
+	// `add` overwrites the balance of an address already loaded, so
+	// the last snapshot line wins.
+	snapshotLines := map[string]UnregdSnapshotLine{}
+	var order []string
+	for {
+		line, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rec.SnapshotRows++
+		rec.SnapshotTotal = rec.SnapshotTotal.Add(line.Balance)
+
+		key := strings.ToLower(line.EthereumAddress)
+		if _, found := snapshotLines[key]; !found {
+			order = append(order, key)
+		}
+		snapshotLines[key] = line
+	}
+
+	for _, key := range order {
+		line := snapshotLines[key]
+		row, found := tableRows[key]
+		if !found {
+			rec.Missing = append(rec.Missing, line)
+			continue
+		}
+
+		if row.Balance != line.Balance {
+			rec.Mismatched = append(rec.Mismatched, &UnregdMismatch{
+				EthereumAddress: line.EthereumAddress,
+				Snapshot:        line.Balance,
+				Table:           row.Balance,
+			})
+		}
+	}
+
+	for key, row := range tableRows {
+		if _, found := snapshotLines[key]; !found {
+			rec.Extra = append(rec.Extra, row)
+		}
+	}
+	sort.Slice(rec.Extra, func(i, j int) bool { return rec.Extra[i].ID < rec.Extra[j].ID })
+
+	return rec, nil
+}
+
+// BalanceGap is what `eosio.unregd` holds beyond the balances
+// recorded in its table. Anything but zero means funds are missing,
+// or stuck in the contract.
+func (r *UnregdReconciliation) BalanceGap() eos.Asset {
+	return r.TokenBalance.Sub(r.TableTotal)
+}
+
+// OK is true when the table matches the snapshot row for row, and
+// the contract holds exactly the table's total.
+func (r *UnregdReconciliation) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Mismatched) == 0 && r.BalanceGap().Amount == 0
+}
+
+// WriteReport prints the reconciliation in a human-readable form.
+func (r *UnregdReconciliation) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "Snapshot: %d rows, %s\n", r.SnapshotRows, r.SnapshotTotal)
+	fmt.Fprintf(w, "Table `addresses`: %d rows, %s\n", r.TableRows, r.TableTotal)
+	fmt.Fprintf(w, "Token balance of `eosio.unregd`: %s\n", r.TokenBalance)
+	fmt.Fprintf(w, "Token balance gap with the table: %s\n", r.BalanceGap())
+	fmt.Fprintln(w, "")
+
+	missingTotal := eos.Asset{Symbol: r.SnapshotTotal.Symbol}
+	for _, line := range r.Missing {
+		missingTotal = missingTotal.Add(line.Balance)
+	}
+	fmt.Fprintf(w, "Missing from the table (not loaded, or claimed): %d rows, %s\n", len(r.Missing), missingTotal)
+	for _, line := range r.Missing {
+		fmt.Fprintf(w, "- %s: %s\n", line.EthereumAddress, line.Balance)
+	}
+
+	fmt.Fprintf(w, "Extra in the table: %d\n", len(r.Extra))
+	for _, row := range r.Extra {
+		fmt.Fprintf(w, "- id %d, %s: %s\n", row.ID, row.EthereumAddress, row.Balance)
+	}
+
+	fmt.Fprintf(w, "Mismatched balances: %d\n", len(r.Mismatched))
+	for _, mismatch := range r.Mismatched {
+		fmt.Fprintf(w, "- %s: %s in snapshot, %s in table\n", mismatch.EthereumAddress, mismatch.Snapshot, mismatch.Table)
+	}
+}
@@ -0,0 +1,47 @@
+package bios
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eoscanada/eos-bios/bios/unregd"
+	eos "github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileUnregd(t *testing.T) {
+	content := `0x00000000000000000000000000000000000000AA,aaaaaaaaaaaa,10.0000
+0x00000000000000000000000000000000000000bb,bbbbbbbbbbbb,20.0000
+0x00000000000000000000000000000000000000cc,cccccccccccc,30.0000
+`
+
+	reader, err := NewUnregdSnapshotReader(bytes.NewBufferString(content), nil, eos.EOSSymbol)
+	require.NoError(t, err)
+
+	rows := []unregd.Address{
+		{ID: 0, EthereumAddress: "0x00000000000000000000000000000000000000aa", Balance: eos.NewEOSAsset(100000)},
+		{ID: 1, EthereumAddress: "0x00000000000000000000000000000000000000bb", Balance: eos.NewEOSAsset(250000)},
+		{ID: 2, EthereumAddress: "0x00000000000000000000000000000000000000dd", Balance: eos.NewEOSAsset(10000)},
+	}
+
+	rec, err := ReconcileUnregd(reader, rows, eos.NewEOSAsset(360000))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, rec.SnapshotRows)
+	assert.Equal(t, eos.NewEOSAsset(600000), rec.SnapshotTotal)
+	assert.Equal(t, 3, rec.TableRows)
+	assert.Equal(t, eos.NewEOSAsset(360000), rec.TableTotal)
+	assert.Equal(t, eos.NewEOSAsset(0), rec.BalanceGap())
+
+	require.Len(t, rec.Missing, 1)
+	assert.Equal(t, "cccccccccccc", rec.Missing[0].AccountName)
+	require.Len(t, rec.Extra, 1)
+	assert.Equal(t, eos.Uint64(2), rec.Extra[0].ID)
+	assert.Equal(t, []*UnregdMismatch{{
+		EthereumAddress: "0x00000000000000000000000000000000000000bb",
+		Snapshot:        eos.NewEOSAsset(200000),
+		Table:           eos.NewEOSAsset(250000),
+	}}, rec.Mismatched)
+	assert.False(t, rec.OK())
+}
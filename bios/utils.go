@@ -7,7 +7,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -75,6 +77,86 @@ func AccountToNodeID(acct eos.AccountName) int64 {
 	return int64(id)
 }
 
+// ValidateAccountName checks `name` follows the rules of account
+// names: up to 12 characters out of `a-z`, `1-5` and `.`, not ending
+// with a `.`.
+func ValidateAccountName(name string) error {
+	if name == "" {
+		return fmt.Errorf("account name is empty")
+	}
+
+	if len(name) > 12 {
+		return fmt.Errorf("account name %q longer than 12 characters", name)
+	}
+
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '1' && c <= '5') && c != '.' {
+			return fmt.Errorf("account name %q has invalid character %q, only a-z, 1-5 and . are allowed", name, c)
+		}
+	}
+
+	if strings.HasSuffix(name, ".") {
+		return fmt.Errorf("account name %q can't end with a '.'", name)
+	}
+
+	return nil
+}
+
+// NewAssetFromString parses amounts like `10`, `10.5` or `10.5000
+// TLOS` in `symbol`, the way `eos.NewEOSAssetFromString` does for
+// `4,EOS`.
+func NewAssetFromString(amount string, symbol eos.Symbol) (out eos.Asset, err error) {
+	amount = strings.TrimSpace(amount)
+	if len(amount) == 0 {
+		return out, fmt.Errorf("cannot be an empty string")
+	}
+
+	if idx := strings.Index(amount, " "); idx != -1 {
+		if code := strings.TrimSpace(amount[idx+1:]); code != symbol.Symbol {
+			return out, fmt.Errorf("amount %q not in core symbol %s", amount, symbol)
+		}
+		amount = amount[:idx]
+	}
+
+	decimals := 0
+	if parts := strings.Split(amount, "."); len(parts) > 2 {
+		return out, fmt.Errorf("cannot have two . in amount")
+	} else if len(parts) == 2 {
+		decimals = len(parts[1])
+	}
+
+	if decimals > int(symbol.Precision) {
+		return out, fmt.Errorf("%s has only %d decimals", symbol.Symbol, symbol.Precision)
+	}
+
+	val, err := strconv.ParseInt(strings.Replace(amount, ".", "", 1), 10, 64)
+	if err != nil {
+		return out, err
+	}
+
+	for i := decimals; i < int(symbol.Precision); i++ {
+		if val > math.MaxInt64/10 || val < math.MinInt64/10 {
+			return out, fmt.Errorf("amount %q overflows", amount)
+		}
+		val *= 10
+	}
+
+	return eos.Asset{Amount: eos.Int64(val), Symbol: symbol}, nil
+}
+
+// rescaleAsset converts an amount expressed in `4,EOS` to `symbol`,
+// adjusting for its precision.
+func rescaleAsset(amount eos.Asset, symbol eos.Symbol) eos.Asset {
+	val := int64(amount.Amount)
+	for p := amount.Symbol.Precision; p < symbol.Precision; p++ {
+		val *= 10
+	}
+	for p := amount.Symbol.Precision; p > symbol.Precision; p-- {
+		val /= 10
+	}
+	return eos.Asset{Amount: eos.Int64(val), Symbol: symbol}
+}
+
 func sha2(input []byte) string {
 	hash := sha256.New()
 	_, _ = hash.Write(input) // can't fail
@@ -0,0 +1,250 @@
+package bios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml2json "github.com/bronze1man/go-yaml2json"
+)
+
+// varReference matches `${name}` in boot sequence strings. `$${`
+// escapes a literal `${`.
+var varReference = regexp.MustCompile(`\$?\$\{([a-zA-Z0-9_.-]*)\}`)
+
+// ParseVarOverrides reads `key=value` pairs, as given to `--set`.
+// Values are parsed as YAML, so `--set count=10` sets a number.
+func ParseVarOverrides(pairs []string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, pair := range pairs {
+		idx := strings.Index(pair, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid override %q, use key=value", pair)
+		}
+
+		jsonCnt, err := yaml2json.Convert([]byte(pair[idx+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("override %q: %s", pair, err)
+		}
+
+		var value interface{}
+		if err := decodeJSON(jsonCnt, &value); err != nil {
+			return nil, fmt.Errorf("override %q: %s", pair, err)
+		}
+		out[pair[:idx]] = value
+	}
+	return out, nil
+}
+
+// ResolveBootSeq turns a YAML boot sequence into JSON with its
+// variables resolved. Variables come from the `vars:` section, with
+// `overrides` taking precedence. A string made of a single `${var}`
+// takes the value of the variable, with its type, and variables inside
+// longer strings are replaced by their text. Variables can refer to
+// other variables, and `${var.field}` reads a field of a map. Steps
+// are then expanded, with `profiles` active, see `StepLoop` and
+// `StepCondition`.
+func ResolveBootSeq(rawYAML []byte, overrides map[string]interface{}, profiles []string) ([]byte, error) {
+	jsonCnt, err := yaml2json.Convert(rawYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := decodeJSON(jsonCnt, &doc); err != nil {
+		return nil, err
+	}
+
+	return resolveVars(doc, overrides, profiles)
+}
+
+// resolveVars is `ResolveBootSeq` on a decoded boot sequence.
+func resolveVars(doc map[string]interface{}, overrides map[string]interface{}, profiles []string) ([]byte, error) {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	var err error
+	vars := map[string]interface{}{}
+	if rawVars, found := doc["vars"]; found && rawVars != nil {
+		declared, ok := rawVars.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("vars: must be a map of names to values")
+		}
+		for name, value := range declared {
+			vars[name] = value
+		}
+	}
+	for name, value := range overrides {
+		if _, found := vars[name]; !found {
+			return nil, fmt.Errorf("--set %s: no such variable in vars", name)
+		}
+		vars[name] = value
+	}
+
+	r := &varResolver{vars: vars, resolved: map[string]interface{}{}, resolving: map[string]bool{}}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := r.lookup(name); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range doc {
+		switch key {
+		case "vars":
+			doc[key] = r.resolved
+		case "boot_sequence":
+		default:
+			doc[key], err = r.resolve(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", key, err)
+			}
+		}
+	}
+
+	expander, err := newStepExpander(r, doc, profiles)
+	if err != nil {
+		return nil, err
+	}
+	if doc["boot_sequence"], err = expander.expand(doc["boot_sequence"]); err != nil {
+		return nil, fmt.Errorf("boot_sequence: %s", err)
+	}
+
+	return json.Marshal(doc)
+}
+
+type varResolver struct {
+	vars      map[string]interface{}
+	resolved  map[string]interface{}
+	resolving map[string]bool
+}
+
+func (r *varResolver) lookup(name string) (interface{}, error) {
+	if value, found := r.resolved[name]; found {
+		return value, nil
+	}
+
+	value, found := r.vars[name]
+	if !found {
+		if idx := strings.Index(name, "."); idx > 0 {
+			return r.lookupField(name[:idx], name[idx+1:])
+		}
+		return nil, fmt.Errorf("undefined variable %q", name)
+	}
+	if r.resolving[name] {
+		return nil, fmt.Errorf("variable %q refers to itself", name)
+	}
+
+	r.resolving[name] = true
+	value, err := r.resolve(value)
+	delete(r.resolving, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.resolved[name] = value
+	return value, nil
+}
+
+// lookupField reads the `path` of dot separated fields in the map
+// variable `name`.
+func (r *varResolver) lookupField(name, path string) (interface{}, error) {
+	value, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range strings.Split(path, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("variable %q isn't a map, it has no field %q", name, field)
+		}
+		if value, ok = fields[field]; !ok {
+			return nil, fmt.Errorf("variable %q has no field %q", name, field)
+		}
+		name = name + "." + field
+	}
+	return value, nil
+}
+
+func (r *varResolver) resolve(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return r.resolveString(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for idx, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			out[idx] = resolved
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for key, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+func (r *varResolver) resolveString(s string) (interface{}, error) {
+	if match := varReference.FindStringSubmatch(s); match != nil && match[0] == s && !strings.HasPrefix(s, "$$") {
+		return r.lookup(match[1])
+	}
+
+	var err error
+	out := varReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		if err != nil {
+			return ref
+		}
+
+		var value interface{}
+		value, err = r.lookup(varReference.FindStringSubmatch(ref)[1])
+		if err != nil {
+			return ref
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}, []interface{}:
+			err = fmt.Errorf("variable %q isn't a scalar, it can't be part of %q", varReference.FindStringSubmatch(ref)[1], s)
+			return ref
+		case nil:
+			return ""
+		default:
+			return fmt.Sprintf("%v", v)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeJSON keeps numbers as `json.Number`, so 64 bits integers
+// survive the round trip.
+func decodeJSON(cnt []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(cnt))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
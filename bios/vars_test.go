@@ -0,0 +1,89 @@
+package bios
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBootSeq(t *testing.T) {
+	resolved, err := ResolveBootSeq([]byte(`
+vars:
+  voters: 10
+  prefix: voter
+  producers: [eosbarbados1, eoscanadacom]
+  greeting: "hello ${prefix}"
+boot_sequence:
+- op: system.create_voters
+  label: Create ${voters} voters named ${prefix}...
+  data:
+    count: ${voters}
+    name_prefix: ${prefix}
+    memo: ${greeting}, costs $${price}
+    producers: ${producers}
+`), map[string]interface{}{"voters": json.Number("25")}, nil)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+  "vars": {"voters": 25, "prefix": "voter", "producers": ["eosbarbados1", "eoscanadacom"], "greeting": "hello voter"},
+  "boot_sequence": [{
+    "op": "system.create_voters",
+    "label": "Create 25 voters named voter...",
+    "data": {"count": 25, "name_prefix": "voter", "memo": "hello voter, costs ${price}", "producers": ["eosbarbados1", "eoscanadacom"]}
+  }]
+}`, string(resolved))
+}
+
+func TestResolveBootSeqErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		overrides map[string]interface{}
+		expectErr string
+	}{
+		{
+			name:      "undefined",
+			yaml:      "boot_sequence:\n- op: system.setram\n  data: {max_bytes: '${ram}'}\n",
+			expectErr: `boot_sequence: step #1: data: undefined variable "ram"`,
+		},
+		{
+			name:      "cycle",
+			yaml:      "vars:\n  a: ${b}\n  b: x${a}\n",
+			expectErr: `variable "a" refers to itself`,
+		},
+		{
+			name:      "non scalar in string",
+			yaml:      "vars:\n  list: [a, b]\nkeys:\n  main: key ${list}\n",
+			expectErr: `keys: variable "list" isn't a scalar, it can't be part of "key ${list}"`,
+		},
+		{
+			name:      "unknown override",
+			yaml:      "vars:\n  a: 1\n",
+			overrides: map[string]interface{}{"b": "2"},
+			expectErr: `--set b: no such variable in vars`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ResolveBootSeq([]byte(test.yaml), test.overrides, nil)
+			assert.EqualError(t, err, test.expectErr)
+		})
+	}
+}
+
+func TestParseVarOverrides(t *testing.T) {
+	vars, err := ParseVarOverrides([]string{"count=10", "prefix=voter", "producers=[a, b]", "memo=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"count":     json.Number("10"),
+		"prefix":    "voter",
+		"producers": []interface{}{"a", "b"},
+		"memo":      "a=b",
+	}, vars)
+
+	_, err = ParseVarOverrides([]string{"count"})
+	assert.EqualError(t, err, `invalid override "count", use key=value`)
+}
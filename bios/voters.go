@@ -0,0 +1,142 @@
+package bios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+)
+
+// voterCharset holds the characters allowed in account names, `a`
+// first so the first voter of `voter` stays `voteraaaaaaa`.
+const voterCharset = "abcdefghijklmnopqrstuvwxyz12345"
+
+// VoterName is the name of voter `index`: `prefix` (`voter` by
+// default) padded to 12 characters with `index` written in the
+// account name charset. `voter` leaves room for 31^7 voters.
+func VoterName(prefix string, index int) (string, error) {
+	if prefix == "" {
+		prefix = "voter"
+	}
+	if err := ValidateAccountName(prefix); err != nil {
+		return "", fmt.Errorf("invalid name_prefix %q: %s", prefix, err)
+	}
+	if len(prefix) > 11 {
+		return "", fmt.Errorf("name_prefix %q leaves no room for an index", prefix)
+	}
+	if index < 0 {
+		return "", fmt.Errorf("negative voter index %d", index)
+	}
+
+	suffix := make([]byte, 12-len(prefix))
+	remainder := index
+	for pos := len(suffix) - 1; pos >= 0; pos-- {
+		suffix[pos] = voterCharset[remainder%len(voterCharset)]
+		remainder /= len(voterCharset)
+	}
+	if remainder != 0 {
+		return "", fmt.Errorf("voter index %d doesn't fit after name_prefix %q", index, prefix)
+	}
+
+	return prefix + string(suffix), nil
+}
+
+// GeneratedVoter is an account created by `system.create_voters`.
+// Keys are only set when derived from a seed.
+type GeneratedVoter struct {
+	Account    eos.AccountName
+	PublicKey  ecc.PublicKey
+	PrivateKey *ecc.PrivateKey
+}
+
+// GenerateVoters names `count` voters after `prefix`. With a
+// `keySeed`, each one gets a key derived from the seed and its name,
+// so the same seed always yields the same keys.
+func GenerateVoters(prefix string, count int, keySeed string) (out []*GeneratedVoter, err error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", count)
+	}
+
+	for i := 0; i < count; i++ {
+		name, err := VoterName(prefix, i)
+		if err != nil {
+			return nil, err
+		}
+
+		voter := &GeneratedVoter{Account: AN(name)}
+		if keySeed != "" {
+			voter.PrivateKey, err = deriveVoterKey(keySeed, voter.Account)
+			if err != nil {
+				return nil, fmt.Errorf("deriving key of %s: %s", name, err)
+			}
+			voter.PublicKey = voter.PrivateKey.PublicKey()
+		}
+
+		out = append(out, voter)
+	}
+
+	return
+}
+
+func deriveVoterKey(seed string, account eos.AccountName) (*ecc.PrivateKey, error) {
+	hash := sha256.Sum256([]byte(seed + "/" + string(account)))
+	return ecc.NewDeterministicPrivateKey(bytes.NewReader(hash[:]))
+}
+
+// keyAuthority is a threshold 1 authority satisfied by any of `keys`,
+// sorted as the chain requires.
+func keyAuthority(keys ...ecc.PublicKey) eos.Authority {
+	sorted := append([]ecc.PublicKey{}, keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Curve != sorted[j].Curve {
+			return sorted[i].Curve < sorted[j].Curve
+		}
+		return bytes.Compare(sorted[i].Content, sorted[j].Content) < 0
+	})
+
+	auth := eos.Authority{Threshold: 1}
+	for _, key := range sorted {
+		auth.Keys = append(auth.Keys, eos.KeyWeight{PublicKey: key, Weight: 1})
+	}
+	return auth
+}
+
+// WriteVotersCSV writes `account,public_key,private_key` lines for
+// `voters`. Voters without a derived key get `ownerKey`, and no
+// private key. The file is only readable by its owner, as it holds
+// private keys.
+func WriteVotersCSV(filename string, voters []*GeneratedVoter, ownerKey ecc.PublicKey) error {
+	fl, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	w := csv.NewWriter(fl)
+	if err := w.Write([]string{"account", "public_key", "private_key"}); err != nil {
+		return err
+	}
+
+	for _, voter := range voters {
+		record := []string{string(voter.Account), ownerKey.String(), ""}
+		if voter.PrivateKey != nil {
+			record[1] = voter.PublicKey.String()
+			record[2] = voter.PrivateKey.String()
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return fl.Close()
+}
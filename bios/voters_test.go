@@ -0,0 +1,126 @@
+package bios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eos "github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/eoscanada/eos-go/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoterName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		index  int
+		out    string
+		err    string
+	}{
+		{"", 0, "voteraaaaaaa", ""},
+		{"", 1, "voteraaaaaab", ""},
+		{"", 30, "voteraaaaaa5", ""},
+		{"", 31, "voteraaaaaba", ""},
+		{"", 5000, "voteraaaafgj", ""},
+		{"load", 0, "loadaaaaaaaa", ""},
+		{"loadtester1", 30, "loadtester15", ""},
+		{"loadtester1", 31, "", `voter index 31 doesn't fit after name_prefix "loadtester1"`},
+		{"loadtester12", 0, "", `name_prefix "loadtester12" leaves no room for an index`},
+		{"Voter", 0, "", `invalid name_prefix "Voter": account name "Voter" has invalid character 'V', only a-z, 1-5 and . are allowed`},
+	}
+
+	seen := map[string]bool{}
+	for _, test := range tests {
+		name, err := VoterName(test.prefix, test.index)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.out, name)
+		assert.NoError(t, ValidateAccountName(name))
+		assert.False(t, seen[name])
+		seen[name] = true
+	}
+}
+
+func TestOpCreateVoters(t *testing.T) {
+	ephemeral, err := ecc.NewRandomPrivateKey()
+	require.NoError(t, err)
+	b := &BIOS{EphemeralPublicKey: ephemeral.PublicKey()}
+
+	csvFile := filepath.Join(os.TempDir(), "eos-bios-voters-test.csv")
+	defer os.Remove(csvFile)
+
+	noRAM := 0
+	op := &OpCreateVoters{
+		Creator:   "eosio",
+		Pubkey:    "ephemeral",
+		Count:     3,
+		Transfer:  "50",
+		RAMBytes:  &noRAM,
+		Stake:     "0",
+		KeySeed:   "load test",
+		ExportCSV: csvFile,
+	}
+
+	acts, err := op.Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 9) // newaccount, transfer and a boundary per voter
+
+	newAccount := acts[3].ActionData.Data.(system.NewAccount)
+	assert.Equal(t, eos.AccountName("voteraaaaaab"), newAccount.Name)
+	require.Len(t, newAccount.Owner.Keys, 1)
+	assert.Equal(t, ephemeral.PublicKey().String(), newAccount.Owner.Keys[0].PublicKey.String())
+	require.Len(t, newAccount.Active.Keys, 2)
+
+	voters, err := GenerateVoters("", 3, "load test")
+	require.NoError(t, err)
+	assert.Contains(t, []string{newAccount.Active.Keys[0].PublicKey.String(), newAccount.Active.Keys[1].PublicKey.String()}, voters[1].PublicKey.String())
+
+	transfer := acts[4].ActionData.Data.(token.Transfer)
+	assert.Equal(t, "50.0000 EOS", transfer.Quantity.String())
+
+	_, err = os.Stat(csvFile)
+	assert.True(t, os.IsNotExist(err), "Actions shouldn't write export_csv")
+
+	require.NoError(t, op.Export(b))
+	stat, err := os.Stat(csvFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	cnt, err := ioutil.ReadFile(csvFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(cnt)), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "account,public_key,private_key", lines[0])
+	assert.Equal(t, "voteraaaaaac,"+voters[2].PublicKey.String()+","+voters[2].PrivateKey.String(), lines[3])
+
+	// Without a seed, voters are created with `pubkey` and default
+	// funding.
+	acts, err = (&OpCreateVoters{Creator: "eosio", Pubkey: "ephemeral", Count: 1}).Actions(b)
+	require.NoError(t, err)
+	require.Len(t, acts, 5)
+	assert.Equal(t, "100000.0000 EOS", acts[1].ActionData.Data.(token.Transfer).Quantity.String())
+	assert.Equal(t, uint32(8192), acts[2].ActionData.Data.(system.BuyRAMBytes).Bytes)
+
+	_, err = (&OpCreateVoters{Creator: "eosio", Pubkey: "ephemeral"}).Actions(b)
+	assert.EqualError(t, err, "count must be positive, got 0")
+}
+
+func TestDeriveVoterKeyIsDeterministic(t *testing.T) {
+	a, err := deriveVoterKey("seed", "voteraaaaaaa")
+	require.NoError(t, err)
+	b, err := deriveVoterKey("seed", "voteraaaaaaa")
+	require.NoError(t, err)
+	c, err := deriveVoterKey("seed", "voteraaaaaab")
+	require.NoError(t, err)
+
+	assert.Equal(t, a.String(), b.String())
+	assert.NotEqual(t, a.String(), c.String())
+}
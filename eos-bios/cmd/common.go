@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/eoscanada/eos-bios/bios"
 	"github.com/eoscanada/eos-go"
 	"github.com/spf13/viper"
@@ -17,5 +19,35 @@ func setupBIOS() (b *bios.BIOS, err error) {
 	b = bios.NewBIOS(logger, viper.GetString("cache-path"), targetNetAPI)
 	b.WriteActions = viper.GetBool("write-actions")
 	b.HackVotingAccounts = viper.GetBool("hack-voting-accounts")
+
+	b.BootSequenceVars, err = bootSeqVars()
+	if err != nil {
+		return nil, err
+	}
+	b.BootSequenceProfiles = bootSeqProfiles()
+
 	return b, nil
 }
+
+// bootSeqVars parses the --set overrides of the boot sequence
+// variables. They are read from the flag directly, so values can hold
+// commas.
+func bootSeqVars() (map[string]interface{}, error) {
+	pairs, err := RootCmd.PersistentFlags().GetStringArray("set")
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := bios.ParseVarOverrides(pairs)
+	if err != nil {
+		return nil, fmt.Errorf("--set: %s", err)
+	}
+	return vars, nil
+}
+
+// bootSeqProfiles returns the boot sequence profiles activated with
+// --profile.
+func bootSeqProfiles() []string {
+	profiles, _ := RootCmd.PersistentFlags().GetStringArray("profile")
+	return profiles
+}
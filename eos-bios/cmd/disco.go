@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoCmd represents the disco command
+var discoCmd = &cobra.Command{
+	Use:   "disco",
+	Short: "Reads and publishes discovery data in the `eosio.disco` contract of a seed network.",
+}
+
+// discoClient connects to the seed network, with `fallbackAPI` used
+// when --seednet-api isn't set. Keys from --seednet-keys are loaded
+// when the file exists.
+func discoClient(fallbackAPI string) *disco.Client {
+	apiURL := viper.GetString("seednet-api")
+	if apiURL == "" {
+		apiURL = fallbackAPI
+	}
+	if apiURL == "" {
+		log.Fatalln("no seed network to talk to, pass --seednet-api")
+	}
+
+	api := eos.New(apiURL)
+
+	keyBag := eos.NewKeyBag()
+	if keysFile := viper.GetString("seednet-keys"); keysFile != "" {
+		if _, err := os.Stat(keysFile); err == nil {
+			if err := keyBag.ImportFromFile(keysFile); err != nil {
+				log.Fatalln("seed network keys:", err)
+			}
+		}
+	}
+	api.SetSigner(keyBag)
+
+	client := disco.NewClient(api)
+	client.Contract = eos.AccountName(viper.GetString("seednet-contract"))
+	client.PageSize = uint32(viper.GetInt("disco-page-size"))
+	return client
+}
+
+func init() {
+	RootCmd.AddCommand(discoCmd)
+
+	discoCmd.PersistentFlags().IntP("page-size", "", 100, "Number of table rows to fetch per request")
+	discoCmd.PersistentFlags().DurationP("stale-after", "", 30*time.Minute, "Participants not updating their discovery row for that long are flagged as stale")
+	discoCmd.PersistentFlags().BoolP("include-stale", "", false, "Include stale participants when computing the mesh and consensus")
+
+	for _, flag := range []string{"page-size", "stale-after", "include-stale"} {
+		if err := viper.BindPFlag("disco-"+flag, discoCmd.PersistentFlags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoConsensusCmd represents the disco consensus command
+var discoConsensusCmd = &cobra.Command{
+	Use:   "consensus",
+	Short: "Shows whether participants agree on the `target_contents` of their discovery files, weighted by trust.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := discoClient("")
+
+		mesh, err := discoveredMesh(client)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		consensus := bios.ComputeContentConsensus(mesh)
+		consensus.WriteReport(os.Stdout)
+
+		if !consensus.Agreed() {
+			fmt.Println("")
+			fmt.Println("NO CONSENSUS: some contents don't have a majority of the trust behind them")
+			os.Exit(1)
+		}
+
+		if contentsFile := viper.GetString("disco-consensus-contents-yaml"); contentsFile != "" {
+			if err := consensus.FetchWinners(viper.GetString("disco-consensus-ipfs-gateway")); err != nil {
+				log.Fatalln("fetching agreed contents:", err)
+			}
+
+			fl, err := os.Create(contentsFile)
+			if err != nil {
+				log.Fatalln("creating contents file:", err)
+			}
+			consensus.WriteContentsYAML(fl)
+			if err := fl.Close(); err != nil {
+				log.Fatalln("writing contents file:", err)
+			}
+			fmt.Printf("\nWrote agreed contents to %q\n", contentsFile)
+		}
+	},
+}
+
+func init() {
+	discoCmd.AddCommand(discoConsensusCmd)
+
+	discoConsensusCmd.Flags().StringP("contents-yaml", "", "", "Write the agreed contents to this file, as the `contents:` block of a boot_sequence.yaml, once downloaded and hashed. Nothing is written without consensus.")
+	discoConsensusCmd.Flags().StringP("ipfs-gateway", "", "https://ipfs.io", "Gateway to download the agreed /ipfs/ refs through")
+
+	for _, flag := range []string{"contents-yaml", "ipfs-gateway"} {
+		if err := viper.BindPFlag("disco-consensus-"+flag, discoConsensusCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+)
+
+// discoDelGenesisCmd represents the disco delgenesis command
+var discoDelGenesisCmd = &cobra.Command{
+	Use:   "delgenesis [account]",
+	Short: "Removes the genesis published by your account on the seed network.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := discoClient("")
+		account := eos.AccountName(args[0])
+
+		if err := client.DeleteGenesis(account); err != nil {
+			log.Fatalln("deleting genesis:", err)
+		}
+
+		fmt.Printf("Deleted genesis of %q\n", account)
+	},
+}
+
+func init() {
+	discoCmd.AddCommand(discoDelGenesisCmd)
+}
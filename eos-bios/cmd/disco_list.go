@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoListCmd represents the disco list command
+var discoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the participants who published a discovery file, flagging the stale ones.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := discoClient("")
+
+		rows, err := client.ListDiscovery()
+		if err != nil {
+			log.Fatalln("listing discovery:", err)
+		}
+
+		genesis, err := client.ListGenesis()
+		if err != nil {
+			log.Fatalln("listing genesis:", err)
+		}
+		hasGenesis := map[eos.AccountName]bool{}
+		for _, row := range genesis {
+			hasGenesis[row.ID] = true
+		}
+
+		now := time.Now().UTC()
+		staleAfter := viper.GetDuration("disco-stale-after")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ACCOUNT\tUPDATED\tTARGET P2P ADDRESS\tGENESIS\tSTATUS")
+		stale := 0
+		for _, row := range rows {
+			status := "active"
+			if row.Stale(now, staleAfter) {
+				status = "STALE"
+				stale++
+			}
+
+			p2pAddress := ""
+			if row.Content != nil {
+				p2pAddress = row.Content.TargetP2PAddress
+			}
+
+			genesisMark := ""
+			if hasGenesis[row.ID] {
+				genesisMark = "yes"
+			}
+
+			fmt.Fprintf(w, "%s\t%s ago\t%s\t%s\t%s\n", row.ID, now.Sub(row.UpdatedAt.Time).Round(time.Second), p2pAddress, genesisMark, status)
+		}
+		w.Flush()
+
+		fmt.Printf("\n%d participants, %d stale (not updated in the last %s)\n", len(rows), stale, staleAfter)
+	},
+}
+
+func init() {
+	discoCmd.AddCommand(discoListCmd)
+}
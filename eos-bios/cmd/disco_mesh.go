@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoMeshCmd represents the disco mesh command
+var discoMeshCmd = &cobra.Command{
+	Use:   "mesh",
+	Short: "Computes the weighted peer graph of the participants, their trust scores and roles.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := discoClient("")
+
+		mesh, err := discoveredMesh(client)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		roles := map[eos.AccountName]string{}
+		if bootNode := mesh.BootNode(); bootNode != nil {
+			roles[bootNode.Account] = "boot node"
+		}
+		for _, peer := range mesh.AppointedBlockProducers(viper.GetInt("disco-mesh-appointed")) {
+			roles[peer.Account] = "appointed block producer"
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RANK\tACCOUNT\tSCORE\tTOTAL WEIGHT\tROLE")
+		for idx, peer := range mesh.Ranked() {
+			fmt.Fprintf(w, "%d\t%s\t%.4f\t%d\t%s\n", idx+1, peer.Account, peer.Score, peer.TotalWeight, roles[peer.Account])
+		}
+		w.Flush()
+
+		if flareFile := viper.GetString("disco-mesh-flare"); flareFile != "" {
+			cnt, err := json.Marshal(mesh.Flare())
+			if err != nil {
+				log.Fatalln("encoding flare:", err)
+			}
+
+			if err := ioutil.WriteFile(flareFile, cnt, 0644); err != nil {
+				log.Fatalln("writing flare:", err)
+			}
+			fmt.Printf("\nWrote graph to %q\n", flareFile)
+		}
+	},
+}
+
+// discoveredMesh builds the mesh out of the discovery rows of the
+// seed network, leaving out the stale ones unless asked otherwise.
+func discoveredMesh(client *disco.Client) (*bios.Mesh, error) {
+	rows, err := client.ListDiscovery()
+	if err != nil {
+		return nil, fmt.Errorf("listing discovery: %s", err)
+	}
+
+	now := time.Now().UTC()
+	staleAfter := viper.GetDuration("disco-stale-after")
+
+	var discoveries []*disco.Discovery
+	for _, row := range rows {
+		if row.Content == nil {
+			continue
+		}
+		if row.Stale(now, staleAfter) && !viper.GetBool("disco-include-stale") {
+			continue
+		}
+
+		// The row's key is authoritative, not what the file claims.
+		row.Content.SeedNetworkAccountName = row.ID
+		discoveries = append(discoveries, row.Content)
+	}
+
+	mesh, err := bios.NewMesh(discoveries)
+	if err != nil {
+		return nil, fmt.Errorf("building mesh: %s", err)
+	}
+	return mesh, nil
+}
+
+func init() {
+	discoCmd.AddCommand(discoMeshCmd)
+
+	discoMeshCmd.Flags().IntP("appointed", "", 21, "Number of appointed block producers, besides the boot node")
+	discoMeshCmd.Flags().StringP("flare", "", "", "Write the graph to this file, in the flare_N.json format of the d3 visualizations")
+
+	for _, flag := range []string{"appointed", "flare"} {
+		if err := viper.BindPFlag("disco-mesh-"+flag, discoMeshCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoPublishCmd represents the disco publish command
+var discoPublishCmd = &cobra.Command{
+	Use:   "publish [my_discovery_file.yaml]",
+	Short: "Publishes your discovery file, and optionally a genesis, to the seed network.",
+	Long: `Publishes your discovery file, and optionally a genesis, to the seed network.
+
+Pushes "updtdisco" as the discovery file's "seed_network_account_name",
+signed with the keys in --seednet-keys. With --genesis, also pushes
+"updtgenesis" with the --p2p-address values.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		discoFile := "my_discovery_file.yaml"
+		if len(args) != 0 {
+			discoFile = args[0]
+		}
+
+		discovery, err := disco.ReadDiscoveryFile(discoFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		account := discovery.SeedNetworkAccountName
+		if account == "" {
+			log.Fatalln("discovery file has no `seed_network_account_name`")
+		}
+
+		client := discoClient(discovery.SeedNetworkHTTPAddress)
+
+		if err := client.PublishDiscovery(account, discovery); err != nil {
+			log.Fatalln("publishing discovery:", err)
+		}
+		fmt.Printf("Published discovery file for %q\n", account)
+
+		genesisFile := viper.GetString("disco-publish-genesis")
+		if genesisFile == "" {
+			return
+		}
+
+		genesisJSON, err := ioutil.ReadFile(genesisFile)
+		if err != nil {
+			log.Fatalln("reading genesis:", err)
+		}
+
+		if err := client.PublishGenesis(account, string(genesisJSON), viper.GetStringSlice("disco-publish-p2p-address")); err != nil {
+			log.Fatalln("publishing genesis:", err)
+		}
+		fmt.Printf("Published genesis for %q\n", account)
+	},
+}
+
+func init() {
+	discoCmd.AddCommand(discoPublishCmd)
+
+	discoPublishCmd.Flags().StringP("genesis", "", "", "genesis.json to publish along with the discovery file")
+	discoPublishCmd.Flags().StringSliceP("p2p-address", "", nil, "Initial p2p address(es) to publish with the genesis")
+
+	for _, flag := range []string{"genesis", "p2p-address"} {
+		if err := viper.BindPFlag("disco-publish-"+flag, discoPublishCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoShowCmd represents the disco show command
+var discoShowCmd = &cobra.Command{
+	Use:   "show [account]",
+	Short: "Shows the discovery file and genesis published by an account.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := discoClient("")
+		account := eos.AccountName(args[0])
+
+		row, err := client.GetDiscovery(account)
+		if err != nil {
+			log.Fatalln("getting discovery:", err)
+		}
+		if row == nil {
+			log.Fatalf("%q didn't publish a discovery file", account)
+		}
+
+		cnt, err := json.MarshalIndent(row.Content, "", "  ")
+		if err != nil {
+			log.Fatalln("formatting discovery:", err)
+		}
+		fmt.Println(string(cnt))
+		fmt.Println("")
+
+		now := time.Now().UTC()
+		staleAfter := viper.GetDuration("disco-stale-after")
+		fmt.Printf("Updated at: %s (%s ago)\n", row.UpdatedAt.Time.Format(time.RFC3339), now.Sub(row.UpdatedAt.Time).Round(time.Second))
+		if row.Stale(now, staleAfter) {
+			fmt.Printf("STALE: not updated in the last %s\n", staleAfter)
+		}
+
+		genesis, err := client.GetGenesis(account)
+		if err != nil {
+			log.Fatalln("getting genesis:", err)
+		}
+		if genesis == nil {
+			fmt.Println("No genesis published.")
+			return
+		}
+
+		fmt.Println("")
+		fmt.Println("Genesis:")
+		fmt.Println(genesis.GenesisJSON)
+		fmt.Println("Initial p2p addresses:")
+		for _, address := range genesis.InitialP2PAddresses {
+			fmt.Printf("- %s\n", address)
+		}
+	},
+}
+
+func init() {
+	discoCmd.AddCommand(discoShowCmd)
+}
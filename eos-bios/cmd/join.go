@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// joinCmd represents the join command
+var joinCmd = &cobra.Command{
+	Use:   "join [boot_sequence.yaml]",
+	Short: "Starts a node on a freshly booted network and validates it against the boot sequence.",
+	Long: `Starts a node on a freshly booted network and validates it against the boot sequence.
+
+The genesis is read from --genesis, a file or an http(s) URL, or prompted
+for. The "join_network" hook receives it along with the --p2p-address
+values, and is expected to start a node reachable at --api-url. Once the
+node receives blocks, all the actions of the boot sequence are checked
+against the chain.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := setupBIOS()
+		if err != nil {
+			log.Fatalln("bios setup:", err)
+		}
+
+		if len(args) == 0 {
+			b.BootSequenceFile = "boot_sequence.yaml"
+		} else {
+			b.BootSequenceFile = args[0]
+		}
+
+		genesisJSON, err := b.ReadGenesis(viper.GetString("join-genesis"))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		p2pAddresses := viper.GetStringSlice("join-p2p-address")
+		if len(p2pAddresses) == 0 {
+			b.Log.Println("WARNING: no --p2p-address given, the `join_network` hook will need to find peers by itself")
+		}
+
+		if err := b.Join(genesisJSON, p2pAddresses, viper.GetBool("join-validate")); err != nil {
+			log.Fatalf("BIOS join error: %s", err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(joinCmd)
+
+	joinCmd.Flags().StringP("genesis", "", "", "genesis.json of the network to join, as a file or http(s) URL. Prompted for when not set.")
+	joinCmd.Flags().StringSliceP("p2p-address", "", nil, "p2p address(es) of nodes of the network to connect to")
+	joinCmd.Flags().BoolP("validate", "", true, "Validate the chain against the boot sequence once the node is synced")
+
+	for _, flag := range []string{"genesis", "p2p-address", "validate"} {
+		if err := viper.BindPFlag("join-"+flag, joinCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
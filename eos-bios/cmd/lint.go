@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [boot_sequence.yaml]",
+	Short: "Checks a boot sequence strictly, before a boot.",
+	Long: `Checks a boot sequence strictly, before a boot.
+
+On top of what a boot checks, it reports misspelled or unknown fields,
+steps missing their "data", invalid account names and public keys,
+contents without a "hash", and "contract_name_ref"s without both their
+".wasm" and ".abi" in "contents". Issues are reported with the file and
+line of the step, or content, they were found in.
+
+Variables and profiles are applied like for a boot, see --set and
+--profile. Exits with a non-zero status when issues are found.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		vars, err := bootSeqVars()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		issues, err := bios.LintBootSeq(args[0], vars, bootSeqProfiles())
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+
+		if len(issues) != 0 {
+			fmt.Println("")
+			fmt.Printf("%d ISSUES FOUND\n", len(issues))
+			os.Exit(1)
+		}
+
+		fmt.Println("No issues found.")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+}
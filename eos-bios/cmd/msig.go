@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// msigCmd represents the msig command
+var msigCmd = &cobra.Command{
+	Use:   "msig",
+	Short: "Tools to follow the `eosio.msig` proposals of `msig.propose` steps.",
+}
+
+func init() {
+	RootCmd.AddCommand(msigCmd)
+}
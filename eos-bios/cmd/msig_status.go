@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// msigStatusCmd represents the msig status command
+var msigStatusCmd = &cobra.Command{
+	Use:   "status [proposer] [proposal_name]",
+	Short: "Shows the approvals given and missing on a proposal.",
+	Long: `Shows the approvals given and missing on a proposal.
+
+Reads the "approvals" table of "eosio.msig" on the network at --api-url.
+Exits with a non-zero status while approvals are missing, so it can gate
+an "msig.exec" step.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		api := eos.New(viper.GetString("api-url"))
+
+		approvals, err := bios.FetchMsigApprovals(api, eos.AccountName(args[0]), eos.Name(args[1]))
+		if err != nil {
+			log.Fatalln("fetching approvals:", err)
+		}
+		if approvals == nil {
+			log.Fatalf("no proposal %q by %q\n", args[1], args[0])
+		}
+
+		approvals.WriteReport(os.Stdout)
+
+		if len(approvals.Missing()) != 0 {
+			fmt.Println("")
+			fmt.Printf("%d APPROVALS MISSING\n", len(approvals.Missing()))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	msigCmd.AddCommand(msigStatusCmd)
+}
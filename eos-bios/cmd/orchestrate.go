@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-bios/eosio.disco/disco"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// orchestrateCmd represents the orchestrate command
+var orchestrateCmd = &cobra.Command{
+	Use:   "orchestrate [my_discovery_file.yaml]",
+	Short: "Coordinates the launch with the other participants through the seed network, then boots or joins.",
+	Long: `Coordinates the launch with the other participants through the seed network, then boots or joins.
+
+Publishes your discovery file and waits for the seed network to reach
+its "seed_network_launch_block". The mesh of the active participants
+then decides the roles: the boot node runs "boot" and publishes its
+genesis, everyone else waits for that genesis, runs the "join_network"
+hook and validates the chain against the boot sequence.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		discoFile := "my_discovery_file.yaml"
+		if len(args) != 0 {
+			discoFile = args[0]
+		}
+
+		myDiscovery, err := disco.ReadDiscoveryFile(discoFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if myDiscovery.SeedNetworkAccountName == "" {
+			log.Fatalln("discovery file has no `seed_network_account_name`")
+		}
+
+		b, err := setupBIOS()
+		if err != nil {
+			log.Fatalln("bios setup:", err)
+		}
+		b.BootSequenceFile = viper.GetString("orchestrate-boot-sequence")
+
+		orchestrator := bios.NewOrchestrator(b, discoClient(myDiscovery.SeedNetworkHTTPAddress), myDiscovery)
+		orchestrator.StaleAfter = viper.GetDuration("orchestrate-stale-after")
+		orchestrator.PollInterval = viper.GetDuration("orchestrate-poll-interval")
+		orchestrator.GenesisTimeout = viper.GetDuration("orchestrate-genesis-timeout")
+
+		if err := orchestrator.Run(); err != nil {
+			log.Fatalf("orchestrate: %s", err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(orchestrateCmd)
+
+	orchestrateCmd.Flags().StringP("boot-sequence", "", "boot_sequence.yaml", "Boot sequence to inject as the boot node, or to validate against otherwise")
+	orchestrateCmd.Flags().DurationP("stale-after", "", 30*time.Minute, "Participants not updating their discovery row for that long before the launch block are left out of the launch")
+	orchestrateCmd.Flags().DurationP("poll-interval", "", 5*time.Second, "Time between checks of the seed network")
+	orchestrateCmd.Flags().DurationP("genesis-timeout", "", 30*time.Minute, "Time to wait for the boot node's genesis before giving up")
+
+	for _, flag := range []string{"boot-sequence", "stale-after", "poll-interval", "genesis-timeout"} {
+		if err := viper.BindPFlag("orchestrate-"+flag, orchestrateCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/spf13/cobra"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan [boot_sequence.yaml]",
+	Short: "Prints the composed boot sequence with its variables resolved.",
+	Long: `Prints the composed boot sequence with its variables resolved.
+
+The sequence is composed with the files it "include:"s and its
+"overlays:". Variables are taken from the "vars:" section of the boot
+sequence, and can be overridden with --set key=value. The sequence is fully parsed, so
+any error a boot would hit reading it is reported here.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		vars, err := bootSeqVars()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if _, err := bios.ReadBootSeq(args[0], vars, bootSeqProfiles()); err != nil {
+			log.Fatalln(err)
+		}
+
+		resolved, err := bios.ReadResolvedBootSeq(args[0], vars, bootSeqProfiles())
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var plan struct {
+			Vars         map[string]json.RawMessage `json:"vars"`
+			BootSequence []struct {
+				ID    string          `json:"id"`
+				Op    string          `json:"op"`
+				Label string          `json:"label"`
+				Data  json.RawMessage `json:"data"`
+			} `json:"boot_sequence"`
+		}
+		if err := json.Unmarshal(resolved, &plan); err != nil {
+			log.Fatalln("parsing resolved boot seq:", err)
+		}
+
+		if len(plan.Vars) != 0 {
+			fmt.Println("Variables:")
+			names := make([]string, 0, len(plan.Vars))
+			for name := range plan.Vars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("  %s = %s\n", name, plan.Vars[name])
+			}
+			fmt.Println("")
+		}
+
+		fmt.Println("Boot sequence:")
+		for idx, step := range plan.BootSequence {
+			if step.ID != "" {
+				fmt.Printf("%d. [%s] %s (id: %s)\n", idx+1, step.Op, step.Label, step.ID)
+			} else {
+				fmt.Printf("%d. [%s] %s\n", idx+1, step.Op, step.Label)
+			}
+			if len(step.Data) == 0 || string(step.Data) == "null" {
+				continue
+			}
+
+			var out bytes.Buffer
+			if err := json.Indent(&out, step.Data, "     ", "  "); err != nil {
+				log.Fatalln("formatting step data:", err)
+			}
+			fmt.Printf("     %s\n", out.String())
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(planCmd)
+}
@@ -62,8 +62,14 @@ func init() {
 	RootCmd.PersistentFlags().BoolP("write-actions", "", false, "Write actions to actions.jsonl upon join or boot")
 	RootCmd.PersistentFlags().StringP("cache-path", "", filepath.Join(homedir, ".eos-bios-cache"), "directory to store cached data from discovered network")
 	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "Display verbose output (also see 'output.log')")
+	RootCmd.PersistentFlags().StringArrayP("set", "", nil, "Override a variable of the boot sequence `vars:`, as key=value. Can be repeated.")
+	RootCmd.PersistentFlags().StringArrayP("profile", "", nil, "Activate a profile of the boot sequence `profiles:`, for the `when:` of its steps. Can be repeated.")
 
-	for _, flag := range []string{"cache-path", "write-actions", "api-url", "verbose", "hack-voting-accounts"} {
+	RootCmd.PersistentFlags().StringP("seednet-api", "", "", "HTTP address of a seed network node. Defaults to `seed_network_http_address` of the discovery file when it is read.")
+	RootCmd.PersistentFlags().StringP("seednet-keys", "", "seed_network.keys", "File with the private key(s) of your seed network account, one per line")
+	RootCmd.PersistentFlags().StringP("seednet-contract", "", seedNetworkContract, "Account of the discovery contract on the seed network")
+
+	for _, flag := range []string{"cache-path", "write-actions", "api-url", "verbose", "hack-voting-accounts", "seednet-api", "seednet-keys", "seednet-contract"} {
 		if err := viper.BindPFlag(flag, RootCmd.PersistentFlags().Lookup(flag)); err != nil {
 			panic(err)
 		}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Tools to inspect snapshots before injecting them in a boot sequence.",
+}
+
+func init() {
+	RootCmd.AddCommand(snapshotCmd)
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// snapshotAuditCmd represents the snapshot audit command
+var snapshotAuditCmd = &cobra.Command{
+	Use:   "audit [snapshot.csv]",
+	Short: "Reports totals, duplicates and invalid rows of a snapshot, and compares it with the boot sequence.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bootSeqFile := viper.GetString("snapshot-audit-boot-sequence")
+
+		var bootSeq *bios.BootSeq
+		if _, err := os.Stat(bootSeqFile); err == nil {
+			vars, err := bootSeqVars()
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			bootSeq, err = bios.ReadBootSeq(bootSeqFile, vars, bootSeqProfiles())
+			if err != nil {
+				log.Fatalln("boot sequence:", err)
+			}
+		} else {
+			fmt.Printf("No boot sequence found at %q, skipping comparison with `token.issue`.\n\n", bootSeqFile)
+		}
+
+		symbol := eos.EOSSymbol
+		if bootSeq != nil && bootSeq.CoreSymbol != nil {
+			symbol = eos.Symbol(*bootSeq.CoreSymbol)
+		}
+		if cmd.Flags().Changed("core-symbol") {
+			var err error
+			symbol, err = eos.StringToSymbol(viper.GetString("snapshot-audit-core-symbol"))
+			if err != nil {
+				log.Fatalln("core symbol:", err)
+			}
+		}
+
+		var createAccounts *bios.OpSnapshotCreateAccounts
+		var loadUnregd *bios.OpInjectUnregdSnapshot
+		var issued *eos.Asset
+		if bootSeq != nil {
+			for _, step := range bootSeq.BootSequence {
+				switch op := step.Data.(type) {
+				case *bios.OpSnapshotCreateAccounts:
+					createAccounts = op
+				case *bios.OpInjectUnregdSnapshot:
+					loadUnregd = op
+				case *bios.OpIssueToken:
+					if op.TokenContract() != eos.AccountName("eosio.token") || op.Amount.Symbol != symbol {
+						continue
+					}
+					if issued == nil {
+						issued = &op.Amount
+					} else {
+						total := issued.Add(op.Amount)
+						issued = &total
+					}
+				}
+			}
+		}
+
+		format := &bios.SnapshotFormat{}
+		var policy *bios.StakePolicy
+		truncate := 0
+		if createAccounts != nil {
+			if createAccounts.Format != nil {
+				format = createAccounts.Format
+			}
+
+			var err error
+			policy, err = createAccounts.Policy(symbol)
+			if err != nil {
+				log.Fatalln("distribution:", err)
+			}
+			truncate = createAccounts.TestnetTruncateSnapshot
+		}
+		if cmd.Flags().Changed("type") {
+			format.Type = viper.GetString("snapshot-audit-type")
+		}
+		if cmd.Flags().Changed("header") {
+			format.Header = viper.GetBool("snapshot-audit-header")
+		}
+
+		fl, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalln("opening snapshot:", err)
+		}
+		defer fl.Close()
+
+		reader, err := bios.NewSnapshotReader(fl, format, symbol)
+		if err != nil {
+			log.Fatalln("snapshot format:", err)
+		}
+
+		audit, err := bios.AuditSnapshot(reader, policy, truncate)
+		if err != nil {
+			log.Fatalln("auditing snapshot:", err)
+		}
+
+		audit.WriteReport(os.Stdout)
+
+		if issued == nil {
+			return
+		}
+
+		var unregdTotal *eos.Asset
+		if unregdFile := viper.GetString("snapshot-audit-unregistered"); unregdFile != "" {
+			var unregdFormat *bios.SnapshotFormat
+			if loadUnregd != nil {
+				unregdFormat = loadUnregd.Format
+			}
+
+			total, err := sumUnregdSnapshot(unregdFile, unregdFormat, symbol)
+			if err != nil {
+				log.Fatalln("unregistered snapshot:", err)
+			}
+			unregdTotal = &total
+		}
+
+		gap, explanations := audit.IssueGap(*issued, unregdTotal)
+
+		fmt.Println("")
+		fmt.Printf("Issued in boot sequence: %s\n", *issued)
+		fmt.Printf("Gap with snapshot total: %s\n", gap)
+		for _, explanation := range explanations {
+			fmt.Printf("- %s\n", explanation)
+		}
+		if unregdTotal == nil && loadUnregd != nil {
+			fmt.Println("- the boot sequence also loads an unregistered snapshot, pass --unregistered to account for it")
+		}
+	},
+}
+
+func sumUnregdSnapshot(filename string, format *bios.SnapshotFormat, symbol eos.Symbol) (total eos.Asset, err error) {
+	fl, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer fl.Close()
+
+	reader, err := bios.NewUnregdSnapshotReader(fl, format, symbol)
+	if err != nil {
+		return
+	}
+
+	total = eos.Asset{Symbol: symbol}
+	for {
+		line, err := reader.Next()
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		total = total.Add(line.Balance)
+	}
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotAuditCmd)
+
+	snapshotAuditCmd.Flags().StringP("boot-sequence", "", "boot_sequence.yaml", "Boot sequence to compare the snapshot with, and to read the snapshot format from.")
+	snapshotAuditCmd.Flags().StringP("unregistered", "", "", "Unregistered snapshot to account for when comparing with the issued amount.")
+	snapshotAuditCmd.Flags().StringP("type", "", "csv", "Snapshot type, one of csv, tsv or jsonl. Overrides the boot sequence format.")
+	snapshotAuditCmd.Flags().BoolP("header", "", false, "Snapshot has a header line. Overrides the boot sequence format.")
+	snapshotAuditCmd.Flags().StringP("core-symbol", "", "4,EOS", "Symbol of the snapshot balances. Overrides the boot sequence core_symbol.")
+
+	for _, flag := range []string{"boot-sequence", "unregistered", "type", "header", "core-symbol"} {
+		if err := viper.BindPFlag("snapshot-audit-"+flag, snapshotAuditCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// unregdCmd represents the unregd command
+var unregdCmd = &cobra.Command{
+	Use:   "unregd",
+	Short: "Tools to interact with the `eosio.unregd` contract, holding the balances of unregistered Ethereum addresses.",
+}
+
+func init() {
+	RootCmd.AddCommand(unregdCmd)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/eoscanada/eos-bios/bios/unregd"
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// unregdClaimCmd represents the unregd claim command
+var unregdClaimCmd = &cobra.Command{
+	Use:   "claim [account_name] [eos_public_key]",
+	Short: "Creates an account out of an unregistered balance, signing the claim with the Ethereum private key.",
+	Long: `Creates an account out of an unregistered balance, signing the claim with the Ethereum private key.
+
+The claim message is signed over the TaPoS of the last irreversible
+block, and pushed in a "regaccount" transaction signed by --pusher. The
+Ethereum private key is read from --eth-key-file, or prompted for without
+echoing it, in hex or WIF format.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		accountName := args[0]
+		if err := unregd.ValidateClaimAccount(accountName); err != nil {
+			log.Fatalln("account name:", err)
+		}
+
+		eosPubKey, err := ecc.NewPublicKey(args[1])
+		if err != nil {
+			log.Fatalln("eos public key:", err)
+		}
+
+		pusher, err := eos.NewPermissionLevel(viper.GetString("unregd-claim-pusher"))
+		if err != nil {
+			log.Fatalln("pusher:", err)
+		}
+
+		keyBag := eos.NewKeyBag()
+		if keyFile := viper.GetString("unregd-claim-pusher-key-file"); keyFile != "" {
+			if err := keyBag.ImportFromFile(keyFile); err != nil {
+				log.Fatalln("pusher key file:", err)
+			}
+		}
+
+		var rawEthKey string
+		if ethKeyFile := viper.GetString("unregd-claim-eth-key-file"); ethKeyFile != "" {
+			content, err := ioutil.ReadFile(ethKeyFile)
+			if err != nil {
+				log.Fatalln("eth key file:", err)
+			}
+			rawEthKey = string(content)
+		} else {
+			fmt.Print("Enter ETH private key (WIF or hex format): ")
+			keyBytes, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			if err != nil {
+				log.Fatalln("reading eth private key:", err)
+			}
+			rawEthKey = string(keyBytes)
+		}
+
+		ethKey, err := unregd.ParseEthereumPrivateKey(rawEthKey)
+		if err != nil {
+			log.Fatalln("eth private key:", err)
+		}
+
+		fmt.Printf("Claiming balance of %s as %q with key %s\n", unregd.EthereumAddress(ethKey.PubKey()), accountName, eosPubKey)
+
+		api := eos.New(viper.GetString("api-url"))
+		api.SetSigner(keyBag)
+
+		attempts := viper.GetInt("unregd-claim-attempts")
+		for attempt := 1; ; attempt++ {
+			info, err := api.GetInfo()
+			if err != nil {
+				log.Fatalln("get info:", err)
+			}
+
+			tx := eos.NewTransaction(nil, &eos.TxOptions{HeadBlockID: info.LastIrreversibleBlockID})
+
+			hash := unregd.PersonalMessageHash(unregd.ClaimMessage(tx.RefBlockNum, tx.RefBlockPrefix, eosPubKey.String(), accountName))
+			signature, err := unregd.SignClaim(ethKey, hash)
+			if err == unregd.ErrNonCanonical {
+				if attempt >= attempts {
+					log.Fatalf("no canonical signature after %d attempts", attempts)
+				}
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			if err != nil {
+				log.Fatalln("signing claim:", err)
+			}
+
+			tx.Actions = []*eos.Action{unregd.NewRegAccount(pusher, signature, accountName, eosPubKey.String())}
+
+			resp, err := api.SignPushTransaction(tx, info.ChainID, eos.CompressionNone)
+			if err != nil {
+				log.Fatalln("pushing regaccount:", err)
+			}
+
+			fmt.Printf("Account %q claimed in transaction %s\n", accountName, resp.TransactionID)
+			return
+		}
+	},
+}
+
+func init() {
+	unregdCmd.AddCommand(unregdClaimCmd)
+
+	unregdClaimCmd.Flags().StringP("pusher", "", "", "account@permission paying for the claim transaction")
+	unregdClaimCmd.Flags().StringP("pusher-key-file", "", "", "File with the private key(s) of --pusher, one per line")
+	unregdClaimCmd.Flags().StringP("eth-key-file", "", "", "File with the Ethereum private key, in hex or WIF format. Prompted for when not set.")
+	unregdClaimCmd.Flags().IntP("attempts", "", 30, "Number of blocks to try signing over until the signature is canonical")
+
+	for _, flag := range []string{"pusher", "pusher-key-file", "eth-key-file", "attempts"} {
+		if err := viper.BindPFlag("unregd-claim-"+flag, unregdClaimCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eoscanada/eos-bios/bios"
+	"github.com/eoscanada/eos-bios/bios/unregd"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// unregdReconcileCmd represents the unregd reconcile command
+var unregdReconcileCmd = &cobra.Command{
+	Use:   "reconcile [snapshot_unregistered.csv]",
+	Short: "Compares the `eosio.unregd` table and token balance with the unregistered snapshot.",
+	Long: `Compares the "eosio.unregd" table and token balance with the unregistered snapshot.
+
+Pages through the "addresses" table of the network at --api-url, matches
+each row with the snapshot by Ethereum address, and lists the missing,
+extra and mismatched rows. It also checks that the contract holds exactly
+the total of its table. Exits with a non-zero status when anything
+differs.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var format *bios.SnapshotFormat
+		symbol := eos.EOSSymbol
+
+		bootSeqFile := viper.GetString("unregd-reconcile-boot-sequence")
+		if _, err := os.Stat(bootSeqFile); err == nil {
+			vars, err := bootSeqVars()
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			bootSeq, err := bios.ReadBootSeq(bootSeqFile, vars, bootSeqProfiles())
+			if err != nil {
+				log.Fatalln("boot sequence:", err)
+			}
+
+			if bootSeq.CoreSymbol != nil {
+				symbol = eos.Symbol(*bootSeq.CoreSymbol)
+			}
+			for _, step := range bootSeq.BootSequence {
+				if op, ok := step.Data.(*bios.OpInjectUnregdSnapshot); ok {
+					format = op.Format
+				}
+			}
+		}
+		if cmd.Flags().Changed("core-symbol") {
+			var err error
+			symbol, err = eos.StringToSymbol(viper.GetString("unregd-reconcile-core-symbol"))
+			if err != nil {
+				log.Fatalln("core symbol:", err)
+			}
+		}
+
+		fl, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalln("opening snapshot:", err)
+		}
+		defer fl.Close()
+
+		reader, err := bios.NewUnregdSnapshotReader(fl, format, symbol)
+		if err != nil {
+			log.Fatalln("snapshot format:", err)
+		}
+
+		api := eos.New(viper.GetString("api-url"))
+
+		rows, err := unregd.FetchAddresses(api, uint32(viper.GetInt("unregd-reconcile-page-size")))
+		if err != nil {
+			log.Fatalln("fetching addresses:", err)
+		}
+
+		balances, err := api.GetCurrencyBalance(eos.AccountName("eosio.unregd"), symbol.Symbol, eos.AccountName("eosio.token"))
+		if err != nil {
+			log.Fatalln("fetching eosio.unregd balance:", err)
+		}
+		tokenBalance := eos.Asset{Symbol: symbol}
+		if len(balances) != 0 {
+			tokenBalance = balances[0]
+		}
+
+		rec, err := bios.ReconcileUnregd(reader, rows, tokenBalance)
+		if err != nil {
+			log.Fatalln("reconciling:", err)
+		}
+
+		rec.WriteReport(os.Stdout)
+
+		if !rec.OK() {
+			fmt.Println("")
+			fmt.Println("RECONCILIATION FAILED")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	unregdCmd.AddCommand(unregdReconcileCmd)
+
+	unregdReconcileCmd.Flags().StringP("boot-sequence", "", "boot_sequence.yaml", "Boot sequence to read the unregistered snapshot format and core symbol from.")
+	unregdReconcileCmd.Flags().StringP("core-symbol", "", "4,EOS", "Symbol of the unregistered balances. Overrides the boot sequence core_symbol.")
+	unregdReconcileCmd.Flags().IntP("page-size", "", 500, "Number of table rows to fetch per request.")
+
+	for _, flag := range []string{"boot-sequence", "core-symbol", "page-size"} {
+		if err := viper.BindPFlag("unregd-reconcile-"+flag, unregdReconcileCmd.Flags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
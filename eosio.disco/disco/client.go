@@ -0,0 +1,209 @@
+package disco
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	yaml2json "github.com/bronze1man/go-yaml2json"
+	"github.com/eoscanada/eos-go"
+)
+
+// Client reads and writes the `discovery` and `genesis` tables of
+// the `eosio.disco` contract on a seed network.
+type Client struct {
+	API      *eos.API
+	Contract eos.AccountName
+	PageSize uint32
+}
+
+func NewClient(api *eos.API) *Client {
+	return &Client{
+		API:      api,
+		Contract: eos.AccountName("eosio.disco"),
+		PageSize: 100,
+	}
+}
+
+// ListDiscovery pages through the whole `discovery` table.
+func (c *Client) ListDiscovery() (out []*DiscoveryRow, err error) {
+	err = c.pageTable("discovery", func(resp *eos.GetTableRowsResp) (string, error) {
+		var rows []*DiscoveryRow
+		if err := resp.JSONToStructs(&rows); err != nil {
+			return "", err
+		}
+		out = append(out, rows...)
+
+		if len(rows) == 0 {
+			return "", nil
+		}
+		return string(rows[len(rows)-1].ID), nil
+	})
+	return
+}
+
+// ListGenesis pages through the whole `genesis` table.
+func (c *Client) ListGenesis() (out []*GenesisRow, err error) {
+	err = c.pageTable("genesis", func(resp *eos.GetTableRowsResp) (string, error) {
+		var rows []*GenesisRow
+		if err := resp.JSONToStructs(&rows); err != nil {
+			return "", err
+		}
+		out = append(out, rows...)
+
+		if len(rows) == 0 {
+			return "", nil
+		}
+		return string(rows[len(rows)-1].ID), nil
+	})
+	return
+}
+
+// GetDiscovery returns the discovery row of `account`, or nil when
+// it didn't publish one.
+func (c *Client) GetDiscovery(account eos.AccountName) (*DiscoveryRow, error) {
+	resp, err := c.getRow("discovery", account)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*DiscoveryRow
+	if err := resp.JSONToStructs(&rows); err != nil {
+		return nil, fmt.Errorf("decoding discovery row: %s", err)
+	}
+
+	if len(rows) == 0 || rows[0].ID != account {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// GetGenesis returns the genesis row of `account`, or nil when it
+// didn't publish one.
+func (c *Client) GetGenesis(account eos.AccountName) (*GenesisRow, error) {
+	resp, err := c.getRow("genesis", account)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*GenesisRow
+	if err := resp.JSONToStructs(&rows); err != nil {
+		return nil, fmt.Errorf("decoding genesis row: %s", err)
+	}
+
+	if len(rows) == 0 || rows[0].ID != account {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// PublishDiscovery pushes `updtdisco` for `account`. The API's signer
+// needs its `active` key.
+func (c *Client) PublishDiscovery(account eos.AccountName, discovery *Discovery) error {
+	return c.push(NewUpdateDiscovery(account, discovery))
+}
+
+// PublishGenesis pushes `updtgenesis` for `account`.
+func (c *Client) PublishGenesis(account eos.AccountName, genesisJSON string, initialP2PAddresses []string) error {
+	return c.push(NewUpdateGenesis(account, genesisJSON, initialP2PAddresses))
+}
+
+// DeleteGenesis pushes `delgenesis` for `account`.
+func (c *Client) DeleteGenesis(account eos.AccountName) error {
+	return c.push(NewDeleteGenesis(account))
+}
+
+func (c *Client) push(action *eos.Action) error {
+	action.Account = c.Contract
+
+	_, err := c.API.SignPushActions(action)
+	if err != nil {
+		return fmt.Errorf("pushing %s: %s", action.Name, err)
+	}
+	return nil
+}
+
+func (c *Client) getRow(table string, account eos.AccountName) (*eos.GetTableRowsResp, error) {
+	key, err := eos.StringToName(string(account))
+	if err != nil {
+		return nil, fmt.Errorf("account name %q: %s", account, err)
+	}
+
+	resp, err := c.API.GetTableRows(eos.GetTableRowsRequest{
+		Code:       string(c.Contract),
+		Scope:      string(c.Contract),
+		Table:      table,
+		LowerBound: strconv.FormatUint(key, 10),
+		Limit:      1,
+		JSON:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get %s table: %s", table, err)
+	}
+	return resp, nil
+}
+
+// pageTable fetches `table` page by page. `decode` consumes a page
+// and returns the last key it saw, the next page starting right
+// after it.
+func (c *Client) pageTable(table string, decode func(resp *eos.GetTableRowsResp) (lastKey string, err error)) error {
+	lowerBound := ""
+	for {
+		resp, err := c.API.GetTableRows(eos.GetTableRowsRequest{
+			Code:       string(c.Contract),
+			Scope:      string(c.Contract),
+			Table:      table,
+			LowerBound: lowerBound,
+			Limit:      c.PageSize,
+			JSON:       true,
+		})
+		if err != nil {
+			return fmt.Errorf("get %s table: %s", table, err)
+		}
+
+		lastKey, err := decode(resp)
+		if err != nil {
+			return fmt.Errorf("decoding %s rows: %s", table, err)
+		}
+
+		if !resp.More || lastKey == "" {
+			return nil
+		}
+
+		key, err := eos.StringToName(lastKey)
+		if err != nil {
+			return fmt.Errorf("%s row key %q: %s", table, lastKey, err)
+		}
+		lowerBound = strconv.FormatUint(key+1, 10)
+	}
+}
+
+// Stale is true when the row wasn't updated in the last `maxAge`.
+// Participants republish their discovery file continuously while
+// orchestrating, so stale rows are from participants gone away.
+func (r *DiscoveryRow) Stale(now time.Time, maxAge time.Duration) bool {
+	return now.Sub(r.UpdatedAt.Time) > maxAge
+}
+
+// ReadDiscoveryFile reads a discovery file, like
+// `my_discovery_file.yaml`, written in YAML or JSON.
+func ReadDiscoveryFile(filename string) (*Discovery, error) {
+	cnt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading discovery file: %s", err)
+	}
+
+	jsonCnt, err := yaml2json.Convert(cnt)
+	if err != nil {
+		return nil, fmt.Errorf("converting discovery file to json: %s", err)
+	}
+
+	var out *Discovery
+	if err := json.Unmarshal(jsonCnt, &out); err != nil {
+		return nil, fmt.Errorf("parsing discovery file: %s", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package disco
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientListDiscovery(t *testing.T) {
+	var lowerBounds []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req eos.GetTableRowsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "discovery", req.Table)
+		lowerBounds = append(lowerBounds, req.LowerBound)
+
+		switch req.LowerBound {
+		case "":
+			w.Write([]byte(`{"more": true, "rows": [
+				{"id": "eosantarctic", "content": {"target_p2p_address": "p2p.antarctic:9876"}, "updated_at": "2018-05-01T12:00:00"},
+				{"id": "eosbarbados1", "content": {"target_p2p_address": "p2p.barbados:9876"}, "updated_at": "2018-05-01T12:20:00"}
+			]}`))
+		default:
+			w.Write([]byte(`{"more": false, "rows": [
+				{"id": "eoscanadacom", "content": {"target_p2p_address": "p2p.canada:9876"}, "updated_at": "2018-05-01T12:25:00"}
+			]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(eos.New(server.URL))
+	client.PageSize = 2
+
+	rows, err := client.ListDiscovery()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	barbados, _ := eos.StringToName("eosbarbados1")
+	assert.Equal(t, []string{"", strconv.FormatUint(barbados+1, 10)}, lowerBounds)
+
+	assert.Equal(t, eos.AccountName("eoscanadacom"), rows[2].ID)
+	assert.Equal(t, "p2p.canada:9876", rows[2].Content.TargetP2PAddress)
+
+	now := time.Date(2018, 5, 1, 12, 40, 0, 0, time.UTC)
+	assert.True(t, rows[0].Stale(now, 30*time.Minute))
+	assert.False(t, rows[1].Stale(now, 30*time.Minute))
+	assert.False(t, rows[2].Stale(now, 30*time.Minute))
+}
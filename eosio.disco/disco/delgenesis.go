@@ -7,7 +7,7 @@ func NewDeleteGenesis(account eos.AccountName) *eos.Action {
 		Account: eos.AccountName("eosio.disco"),
 		Name:    eos.ActionName("delgenesis"),
 		Authorization: []eos.PermissionLevel{
-			{account, eos.PermissionName("active")},
+			{Actor: account, Permission: eos.PermissionName("active")},
 		},
 		ActionData: eos.NewActionData(DelGenesis{
 			Account: account,
@@ -7,7 +7,7 @@ func NewUpdateDiscovery(account eos.AccountName, discovery *Discovery) *eos.Acti
 		Account: eos.AccountName("eosio.disco"),
 		Name:    eos.ActionName("updtdisco"),
 		Authorization: []eos.PermissionLevel{
-			{account, eos.PermissionName("active")},
+			{Actor: account, Permission: eos.PermissionName("active")},
 		},
 		ActionData: eos.NewActionData(UpdtDisco{
 			Account:   account,
@@ -8,7 +8,7 @@ func NewUpdateGenesis(account eos.AccountName, genesisJSON string, initialP2PAdd
 		Account: eos.AccountName("eosio.disco"),
 		Name:    eos.ActionName("updtgenesis"),
 		Authorization: []eos.PermissionLevel{
-			{account, eos.PermissionName("active")},
+			{Actor: account, Permission: eos.PermissionName("active")},
 		},
 		ActionData: eos.NewActionData(UpdtGenesis{
 			Account:             account,